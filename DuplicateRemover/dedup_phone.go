@@ -1,48 +1,31 @@
 package main
 
 import (
-	"encoding/csv"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
-	"regexp"
-)
-
-// Clean non-digit characters out of phone numbers
-func normalizePhone(phone string) string {
-	re := regexp.MustCompile(`\D`)
-	num := re.ReplaceAllString(phone, "")
-	if len(num) == 11 && num[0] == '1' {
-		num = num[1:]
-	}
-	return num
-}
-
-func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: go run dedup_phone.go <input.csv>")
-		os.Exit(1)
-	}
 
-	inFile := os.Args[1]
-	outFile := "clean.csv"
-
-	// --- Open input ---
-	f, err := os.Open(inFile)
-	if err != nil {
-		log.Fatalf("Error opening input file: %v", err)
-	}
-	defer f.Close()
+	"leadkit/csvio"
+	"leadkit/dedup"
+)
 
-	reader := csv.NewReader(f)
-	reader.LazyQuotes = true
-	rows, err := reader.ReadAll()
-	if err != nil {
-		log.Fatalf("Error reading CSV: %v", err)
+// dedupFile removes rows whose phone1 repeats an earlier row's, writing the
+// rest to outFile with phone1 normalized. It returns the number of data rows
+// read and the number of duplicates removed.
+func dedupFile(inFile, outFile string) (int, int, error) {
+	// --- Read input ---
+	rows, err := csvio.ReadAll(inFile, csvio.ReadOptions{LazyQuotes: true})
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return 0, 0, fmt.Errorf("Error opening input file: %v", err)
+	} else if err != nil {
+		return 0, 0, fmt.Errorf("Error reading CSV: %v", err)
 	}
 
 	if len(rows) < 2 {
-		log.Fatalf("CSV appears empty or missing data rows")
+		return 0, 0, fmt.Errorf("CSV appears empty or missing data rows")
 	}
 
 	header := rows[0]
@@ -54,48 +37,53 @@ func main() {
 		}
 	}
 	if colIndex == -1 {
-		log.Fatalf("No 'phone1' column found in CSV headers")
+		return 0, 0, fmt.Errorf("No 'phone1' column found in CSV headers")
 	}
 
 	// --- Deduplicate ---
-	seen := make(map[string]bool)
-	cleaned := [][]string{header}
+	seen := dedup.NewPhoneIndex()
+	var cleaned [][]string
 	duplicates := 0
 
-	for _, row := range rows[1:] {
+	for i, row := range rows[1:] {
 		if colIndex >= len(row) {
 			continue
 		}
-		phone := normalizePhone(row[colIndex])
+		phone, _, dup := seen.See(row[colIndex], i+2)
 		if phone == "" {
 			cleaned = append(cleaned, row)
 			continue
 		}
-		if seen[phone] {
+		if dup {
 			duplicates++
 			continue // skip duplicate
 		}
-		seen[phone] = true
 		row[colIndex] = phone
 		cleaned = append(cleaned, row)
 	}
 
 	// --- Write output ---
-	out, err := os.Create(outFile)
-	if err != nil {
-		log.Fatalf("Error creating output file: %v", err)
+	if err := csvio.WriteAll(outFile, header, cleaned); err != nil {
+		return 0, 0, fmt.Errorf("Error writing CSV: %v", err)
 	}
-	defer out.Close()
+	return len(rows) - 1, duplicates, nil
+}
 
-	writer := csv.NewWriter(out)
-	defer writer.Flush()
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println("Usage: go run dedup_phone.go <input.csv>")
+		os.Exit(1)
+	}
+
+	inFile := os.Args[1]
+	outFile := "clean.csv"
 
-	err = writer.WriteAll(cleaned)
+	total, duplicates, err := dedupFile(inFile, outFile)
 	if err != nil {
-		log.Fatalf("Error writing CSV: %v", err)
+		log.Fatal(err)
 	}
 
-	fmt.Printf("✅ %d total rows processed\n", len(rows)-1)
+	fmt.Printf("✅ %d total rows processed\n", total)
 	fmt.Printf("🗑️  %d duplicate rows removed\n", duplicates)
 	fmt.Printf("📄 Clean CSV written to %s\n", outFile)
 }
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testdata/leads_expected.csv is the clean.csv the tool wrote before it moved
+// onto leadkit; the shared dedup must produce it byte for byte.
+func TestDedupFileMatchesExpectedOutput(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "clean.csv")
+	total, duplicates, err := dedupFile("testdata/leads.csv", out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 9 || duplicates != 3 {
+		t.Errorf("processed %d rows with %d duplicates, want 9 and 3", total, duplicates)
+	}
+
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("testdata/leads_expected.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("output differs from testdata/leads_expected.csv:\n%s", got)
+	}
+}
+
+func TestDedupFileNeedsPhone1Column(t *testing.T) {
+	in := filepath.Join(t.TempDir(), "in.csv")
+	if err := os.WriteFile(in, []byte("id,phone\n1,3055551234\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := dedupFile(in, filepath.Join(t.TempDir(), "clean.csv")); err == nil {
+		t.Error("expected an error without a phone1 column")
+	}
+}
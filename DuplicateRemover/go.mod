@@ -1,3 +1,7 @@
 module dedup_phone
 
 go 1.25.1
+
+require leadkit v0.0.0
+
+replace leadkit => ../leadkit
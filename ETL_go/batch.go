@@ -0,0 +1,198 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"etl_go/extract"
+	"etl_go/load"
+	"etl_go/recipe"
+
+	"astguiclient"
+)
+
+// batchStep is one entry of a --steps list: a command name and its arguments.
+type batchStep struct {
+	name string
+	args []string
+}
+
+// runBatch runs a list of steps without the TUI, printing progress to stderr.
+//
+//	etl_go run --input leads.xlsx --steps drop:9,10,12,clean-all --out leads_clean.csv --report report.json
+func runBatch(args []string, progress io.Writer) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	input := fs.String("input", "", "input .csv or .xlsx file")
+	stepList := fs.String("steps", "clean-all", "comma-separated steps, e.g. drop:9,10,12,clean-all")
+	out := fs.String("out", "", "output .csv or .xlsx file (default <input>_cleaned.csv); not with a write-csv or write-xlsx step")
+	recipeFile := fs.String("recipe", "", "run the steps from a recipe file instead of --steps")
+	reportFile := fs.String("report", "", "write the summary report to this file (.json for JSON, .html for a web page, otherwise text)")
+	rejectsFile := fs.String("rejects", "", "write rejected rows to this file (.xlsx or .csv)")
+	areaCodeFile := fs.String("area-codes", "", "use this area code table instead of the built-in one")
+	zipFile := fs.String("zip-data", "", "use this ZIP table (.csv or .csv.gz) instead of the built-in seed")
+	sheet := fs.String("sheet", "", "worksheet to load from an .xlsx file, by name or 0-based index")
+	mergeSheets := fs.String("merge-sheets", "", "stack worksheets sharing a header: all, or a comma-separated list")
+	delimiter := fs.String("delimiter", "auto", "CSV field separator: auto, \",\", tab, \"|\" or \";\"")
+	encoding := fs.String("encoding", "auto", "CSV text encoding: auto, utf-8, windows-1252, latin-1, utf-16le or utf-16be")
+	ragged := fs.String("ragged", "pad", "rows with too few or too many fields: pad, truncate or reject")
+	conf := astguiclient.Flag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *input == "" && fs.NArg() > 0 {
+		*input = fs.Arg(0)
+	}
+	if *input == "" {
+		return fmt.Errorf("usage: etl_go run --input <file> [--steps drop:9,10,12,clean-all] [--out file.csv] [--report report.json]")
+	}
+
+	var steps []batchStep
+	var mapping map[extract.Role]string
+	if *recipeFile != "" {
+		r, err := recipe.Load(*recipeFile)
+		if err != nil {
+			return err
+		}
+		if err := r.Validate(isStepName); err != nil {
+			return err
+		}
+		for _, st := range r.Steps {
+			steps = append(steps, batchStep{name: st.Name, args: st.Args})
+		}
+		mapping = r.Mapping()
+	} else {
+		var err error
+		if steps, err = parseSteps(*stepList); err != nil {
+			return err
+		}
+	}
+
+	// The steps' own write names the output file, so --out would be ignored
+	if *out != "" {
+		for _, st := range steps {
+			if st.name == "write-csv" || st.name == "write-xlsx" {
+				return fmt.Errorf("--out can't be used with a %s step; give the file to the step (%s:%s) instead", st.name, st.name, *out)
+			}
+		}
+	}
+
+	for kind, path := range map[string]string{"area-codes": *areaCodeFile, "zips": *zipFile} {
+		if path == "" {
+			continue
+		}
+		lines, err := updateGeoData(kind, path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(progress, strings.Join(lines, "\n"))
+	}
+
+	ds, err := extract.ReadFile(*input, extract.ReadOptions{
+		Sheet:       *sheet,
+		MergeSheets: splitList(*mergeSheets),
+		Delimiter:   *delimiter,
+		Encoding:    *encoding,
+		Ragged:      extract.RaggedPolicy(*ragged),
+	})
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", *input, err)
+	}
+	fmt.Fprintln(progress, strings.Join(loadedLines(ds), "\n"))
+
+	for role, header := range mapping {
+		ds = ds.MapColumn(role, header)
+	}
+
+	s := newSession(ds)
+	s.confPath = *conf
+	s.noReports = true // only --report or write-report:<file> write one
+	wroteCSV := false
+	for i, st := range steps {
+		fmt.Fprintf(progress, "[%d/%d] %s %s\n", i+1, len(steps), st.name, strings.Join(st.args, " "))
+		lines, err := s.runStep(st.name, st.args)
+		for _, line := range lines {
+			fmt.Fprintf(progress, "  %s\n", line)
+		}
+		if err != nil {
+			return fmt.Errorf("step %s: %w", st.name, err)
+		}
+		if st.name == "write-csv" || st.name == "write-xlsx" {
+			wroteCSV = true
+		}
+	}
+
+	if !wroteCSV {
+		outFile := *out
+		if outFile == "" {
+			outFile = load.CleanedFileName(ds.Source)
+		}
+		write := "write-csv"
+		if strings.EqualFold(filepath.Ext(outFile), ".xlsx") {
+			write = "write-xlsx"
+		}
+		lines, err := s.runStep(write, []string{outFile})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(progress, strings.Join(lines, "\n"))
+	}
+
+	if *rejectsFile != "" {
+		lines, err := s.runStep("write-rejects", []string{*rejectsFile})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(progress, strings.Join(lines, "\n"))
+	}
+
+	if *reportFile != "" {
+		if err := load.SaveReport(s.report(), *reportFile); err != nil {
+			return err
+		}
+		fmt.Fprintf(progress, "Report written to %s\n", *reportFile)
+	}
+	return nil
+}
+
+// parseSteps splits a --steps value into steps. Arguments follow the step
+// name after a colon; a bare number continues the previous step's argument
+// list, so "drop:9,10,12,clean-all" is drop(9 10 12) followed by clean-all.
+// Every step name must be one runStep knows.
+func parseSteps(spec string) ([]batchStep, error) {
+	var steps []batchStep
+	for _, tok := range strings.Split(spec, ",") {
+		tok = strings.TrimSpace(tok)
+		if tok == "" {
+			continue
+		}
+		if isNumber(tok) && len(steps) > 0 {
+			last := &steps[len(steps)-1]
+			last.args = append(last.args, tok)
+			continue
+		}
+
+		parts := strings.Split(tok, ":")
+		name := strings.ToLower(parts[0])
+		if !isStepName(name) {
+			return nil, fmt.Errorf("unknown step %q in --steps", parts[0])
+		}
+		steps = append(steps, batchStep{name: name, args: parts[1:]})
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("no steps given")
+	}
+	return steps, nil
+}
+
+func isNumber(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return s != ""
+}
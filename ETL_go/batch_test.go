@@ -0,0 +1,116 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseSteps(t *testing.T) {
+	got, err := parseSteps("drop:9,10,12,clean-all,write-csv:out.csv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []batchStep{
+		{name: "drop", args: []string{"9", "10", "12"}},
+		{name: "clean-all", args: []string{}},
+		{name: "write-csv", args: []string{"out.csv"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestParseStepsRejectsUnknownStep(t *testing.T) {
+	if _, err := parseSteps("clean-all,scrub"); err == nil {
+		t.Error("expected an error for unknown step 'scrub'")
+	}
+}
+
+// batchInput writes a small lead file to dir and returns its path.
+func batchInput(t *testing.T, dir string) string {
+	t.Helper()
+	path := filepath.Join(dir, "leads.csv")
+	data := "First,Last,Address,City,State,Zip,Phone,Email\n" +
+		"Ann,Lee,1 Elm St,Tampa,FL,33610,8135551111,ann@example.com\n" +
+		"Bob,Ray,9 Oak Ave,Austin,TX,73301,5125553333,\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// files lists the names in dir.
+func files(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestRunWritesOnlyRequestedFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir) // default report names are relative to the working directory
+	input := batchInput(t, dir)
+
+	err := runBatch([]string{"--input", input, "--steps", "clean-all", "--out", "clean.csv", "--report", "summary.json"}, io.Discard)
+	if err != nil {
+		t.Fatalf("runBatch: %v", err)
+	}
+	// clean-all's write-report step must not add leads_report.* files
+	if got, want := files(t, dir), []string{"clean.csv", "leads.csv", "summary.json"}; !slices.Equal(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+
+	err = runBatch([]string{"--input", input, "--steps", "write-report:own.txt"}, io.Discard)
+	if err != nil {
+		t.Fatalf("runBatch: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "own.txt")); err != nil {
+		t.Errorf("write-report with a file didn't write it: %v", err)
+	}
+}
+
+func TestRunRejectsOutWithWriteStep(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	input := batchInput(t, dir)
+
+	err := runBatch([]string{"--input", input, "--steps", "clean-all,write-csv:mine.csv", "--out", "other.csv"}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "--out") {
+		t.Fatalf("expected an error about --out, got %v", err)
+	}
+	if got := files(t, dir); !slices.Equal(got, []string{"leads.csv"}) {
+		t.Errorf("files = %v, want only the input", got)
+	}
+}
+
+func TestRunOverwritesRejectsFileWhenNothingRejected(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	input := batchInput(t, dir)
+	if err := os.WriteFile("rejects.csv", []byte("source_line,reason\n9,stale\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runBatch([]string{"--input", input, "--steps", "clean-names", "--out", "clean.csv", "--rejects", "rejects.csv"}, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile("rejects.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != "source_line,reason\n" {
+		t.Errorf("rejects.csv = %q, want just the header", got)
+	}
+}
@@ -0,0 +1,211 @@
+package extract
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// sniffSize is how much of a file is inspected to guess its format.
+const sniffSize = 64 * 1024
+
+// Encodings ReadOptions.Encoding accepts besides "auto".
+var encodings = map[string]encoding.Encoding{
+	"utf-8":        unicode.UTF8,
+	"windows-1252": charmap.Windows1252,
+	"latin-1":      charmap.ISO8859_1,
+	"utf-16le":     unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM),
+	"utf-16be":     unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM),
+}
+
+// Delimiters ReadOptions.Delimiter accepts by name, and the sniffing candidates.
+var delimiters = map[string]rune{
+	",":     ',',
+	"comma": ',',
+	"tab":   '\t',
+	`\t`:    '\t',
+	"|":     '|',
+	"pipe":  '|',
+	";":     ';',
+	"semi":  ';',
+}
+
+// CSVFormat records how a delimited file was decoded.
+type CSVFormat struct {
+	Delimiter rune
+	Encoding  string
+	BOM       bool // a byte order mark was found and dropped
+}
+
+// String describes the format for the "Loaded N rows" line.
+func (f CSVFormat) String() string {
+	name := map[rune]string{',': "comma", '\t': "tab", '|': "pipe", ';': "semicolon"}[f.Delimiter]
+	if name == "" {
+		name = fmt.Sprintf("%q", f.Delimiter)
+	}
+	s := fmt.Sprintf("%s-delimited, %s", name, f.Encoding)
+	if f.BOM {
+		s += " with BOM"
+	}
+	return s
+}
+
+// decodeCSV wraps r so it yields UTF-8 without a BOM, and works out the
+// delimiter. Empty options fields, or "auto", are detected from the start
+// of the file.
+func decodeCSV(r io.Reader, opts ReadOptions) (io.Reader, CSVFormat, error) {
+	br := bufio.NewReaderSize(r, sniffSize)
+	sample, err := br.Peek(sniffSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, CSVFormat{}, err
+	}
+
+	var format CSVFormat
+	var enc encoding.Encoding
+	bomLen := 0
+
+	switch name := strings.ToLower(opts.Encoding); name {
+	case "", "auto":
+		format.Encoding, bomLen = sniffEncoding(sample)
+		enc = encodings[format.Encoding]
+	default:
+		var ok bool
+		if enc, ok = encodings[name]; !ok {
+			return nil, CSVFormat{}, fmt.Errorf("unknown encoding %q (expected auto, utf-8, windows-1252, latin-1, utf-16le or utf-16be)", opts.Encoding)
+		}
+		format.Encoding = name
+		_, bomLen = sniffEncoding(sample) // a BOM is still dropped when the encoding is forced
+	}
+	format.BOM = bomLen > 0
+
+	if _, err := br.Discard(bomLen); err != nil {
+		return nil, CSVFormat{}, err
+	}
+	var decoded io.Reader = br
+	if format.Encoding != "utf-8" {
+		decoded = transform.NewReader(br, enc.NewDecoder())
+	}
+
+	switch d := opts.Delimiter; d {
+	case "", "auto":
+		text, err := enc.NewDecoder().Bytes(sample[bomLen:])
+		if err != nil {
+			text = sample[bomLen:]
+		}
+		format.Delimiter = sniffDelimiter(text)
+	default:
+		delim, ok := delimiters[strings.ToLower(d)]
+		if !ok {
+			if r, size := utf8.DecodeRuneInString(d); size == len(d) && r != '"' && r != '\n' {
+				delim, ok = r, true
+			}
+		}
+		if !ok {
+			return nil, CSVFormat{}, fmt.Errorf("invalid delimiter %q", d)
+		}
+		format.Delimiter = delim
+	}
+
+	return decoded, format, nil
+}
+
+// sniffEncoding guesses the encoding of the start of a file and returns the
+// length of its byte order mark, if any.
+func sniffEncoding(sample []byte) (string, int) {
+	switch {
+	case bytes.HasPrefix(sample, []byte{0xEF, 0xBB, 0xBF}):
+		return "utf-8", 3
+	case bytes.HasPrefix(sample, []byte{0xFF, 0xFE}):
+		return "utf-16le", 2
+	case bytes.HasPrefix(sample, []byte{0xFE, 0xFF}):
+		return "utf-16be", 2
+	}
+
+	// UTF-16 without a BOM: ASCII text leaves every other byte zero
+	if len(sample) >= 4 {
+		var evenZero, oddZero int
+		for i, b := range sample {
+			if b == 0 {
+				if i%2 == 0 {
+					evenZero++
+				} else {
+					oddZero++
+				}
+			}
+		}
+		half := len(sample) / 2
+		switch {
+		case oddZero > half*3/4 && evenZero == 0:
+			return "utf-16le", 0
+		case evenZero > half*3/4 && oddZero == 0:
+			return "utf-16be", 0
+		}
+	}
+
+	// The sample may end part-way through a multi-byte character
+	trimmed := sample
+	for i := 0; i < utf8.UTFMax && len(trimmed) > 0 && !utf8.Valid(trimmed); i++ {
+		trimmed = trimmed[:len(trimmed)-1]
+	}
+	if utf8.Valid(trimmed) {
+		return "utf-8", 0
+	}
+	return "windows-1252", 0
+}
+
+// sniffDelimiter picks the candidate that splits the first lines into the
+// same, largest number of fields. Quoted sections are ignored. Comma wins
+// when nothing stands out.
+func sniffDelimiter(sample []byte) rune {
+	lines := strings.Split(string(sample), "\n")
+	if len(lines) > 1 {
+		lines = lines[:len(lines)-1] // the last line may be cut off
+	}
+	if len(lines) > 20 {
+		lines = lines[:20]
+	}
+
+	best, bestScore := ',', 0
+	for _, d := range []rune{',', '\t', '|', ';'} {
+		counts := make(map[int]int)
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			counts[countOutsideQuotes(line, d)]++
+		}
+		// Score the most common non-zero per-line count by how many lines share it
+		for n, freq := range counts {
+			if n == 0 {
+				continue
+			}
+			if score := freq*1000 + n; score > bestScore {
+				best, bestScore = d, score
+			}
+		}
+	}
+	return best
+}
+
+// countOutsideQuotes counts d in line, skipping double-quoted sections.
+func countOutsideQuotes(line string, d rune) int {
+	n := 0
+	quoted := false
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == d && !quoted:
+			n++
+		}
+	}
+	return n
+}
@@ -55,19 +55,9 @@ func (ds *DataSet) FirstNLines(n int, availableWidth int) []string {
 		for j := 0; j < len(ds.Headers); j++ {
 			val := ""
 			if j < len(row) {
-				val = strings.ReplaceAll(row[j], "\n", " ")
+				val = row[j]
 			}
-			width := colWidths[j]
-			if len(val) > width {
-				if width > 3 {
-					val = val[:width-3] + "..."
-				} else if width > 0 {
-					val = val[:width]
-				} else {
-					val = ""
-				}
-			}
-			line += fmt.Sprintf(" %-*s |", width, val)
+			line += fmt.Sprintf(" %-*s |", colWidths[j], FitCell(val, colWidths[j]))
 		}
 		lines = append(lines, line)
 	}
@@ -81,6 +71,29 @@ func (ds *DataSet) FirstNLines(n int, availableWidth int) []string {
 	return lines
 }
 
+// ColumnWidths sizes ds's columns for rows[start:end] the way the show
+// preview does: each column as wide as its header and values, scaled down
+// when the total would exceed availableWidth.
+func (ds *DataSet) ColumnWidths(start, end, availableWidth int) []int {
+	return calculateAdaptiveColumnWidths(ds.Headers, ds.Rows[start:end], availableWidth)
+}
+
+// FitCell flattens newlines in val and cuts it to width, marking the cut
+// with "...".
+func FitCell(val string, width int) string {
+	val = strings.ReplaceAll(val, "\n", " ")
+	if len(val) <= width {
+		return val
+	}
+	if width > 3 {
+		return val[:width-3] + "..."
+	}
+	if width > 0 {
+		return val[:width]
+	}
+	return ""
+}
+
 // calculateAdaptiveColumnWidths optimizes column widths for the available space
 func calculateAdaptiveColumnWidths(headers []string, rows [][]string, availableWidth int) []int {
 	colWidths := make([]int, len(headers))
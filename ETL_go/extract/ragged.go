@@ -0,0 +1,199 @@
+package extract
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"etl_go/types"
+)
+
+// RaggedPolicy decides what happens to rows whose field count differs from
+// the header's. Blank cells past the header are always dropped first, so a
+// trailing delimiter never makes a row ragged.
+type RaggedPolicy string
+
+const (
+	// RaggedPad pads short rows with blank cells and rejects rows that have
+	// data past the last header. This is the default.
+	RaggedPad RaggedPolicy = "pad"
+	// RaggedTruncate pads short rows and cuts long ones to the header width,
+	// discarding the extra cells.
+	RaggedTruncate RaggedPolicy = "truncate"
+	// RaggedReject rejects every row that is short or has data past the
+	// last header.
+	RaggedReject RaggedPolicy = "reject"
+)
+
+// ParseRaggedPolicy reads a policy name; empty means RaggedPad.
+func ParseRaggedPolicy(s string) (RaggedPolicy, error) {
+	switch p := RaggedPolicy(strings.ToLower(strings.TrimSpace(s))); p {
+	case "":
+		return RaggedPad, nil
+	case RaggedPad, RaggedTruncate, RaggedReject:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown ragged row policy %q (expected pad, truncate or reject)", s)
+	}
+}
+
+// shaper brings every row to the header's width under a RaggedPolicy, while
+// counting field counts and spotting rows that look shifted.
+type shaper struct {
+	headers []string
+	policy  RaggedPolicy
+	typed   []typedColumn
+
+	stats   ShapeStats
+	rejects []types.Reject
+}
+
+// typedColumn is a column whose values have a recognizable shape, used to
+// tell whether a long row's later fields moved one or more places right.
+type typedColumn struct {
+	idx   int
+	valid func(string) bool
+}
+
+func newShaper(headers []string, policy RaggedPolicy) *shaper {
+	s := &shaper{
+		headers: headers,
+		policy:  policy,
+		stats:   ShapeStats{Width: len(headers), Policy: policy, Counts: make(map[int]int)},
+	}
+	schema := NewSchema(headers, nil)
+	for role, valid := range map[Role]func(string) bool{
+		RoleState: looksLikeState,
+		RoleZip:   looksLikeZip,
+		RolePhone: looksLikePhone,
+	} {
+		if i := schema.Lookup(role); i >= 0 {
+			s.typed = append(s.typed, typedColumn{idx: i, valid: valid})
+		}
+	}
+	return s
+}
+
+// shape returns row at the header's width, or false when the policy rejects
+// it; the reject is recorded.
+func (s *shaper) shape(line int, row []string) ([]string, bool) {
+	width := len(s.headers)
+
+	// Blank cells past the header carry nothing, e.g. a trailing delimiter
+	used := len(row)
+	for used > width && strings.TrimSpace(row[used-1]) == "" {
+		used--
+	}
+	s.stats.Counts[used]++
+	if len(row) == width {
+		return row, true
+	}
+
+	switch {
+	case used == width:
+		return row[:width], true
+	case used > width:
+		if s.looksShifted(row) {
+			s.stats.Shifted = append(s.stats.Shifted, line)
+		}
+		if s.policy == RaggedTruncate {
+			return row[:width], true
+		}
+	case s.policy != RaggedReject:
+		padded := make([]string, width)
+		copy(padded, row)
+		return padded, true
+	}
+
+	s.rejects = append(s.rejects, types.Reject{
+		Line:    line,
+		Reason:  fmt.Sprintf("%s:%d_columns", types.ReasonMalformedRow, len(row)),
+		Row:     row,
+		Headers: s.headers,
+	})
+	return nil, false
+}
+
+// looksShifted reports whether a long row's typed columns (state, ZIP,
+// phone) fit better when read that many places to the right, as happens
+// when an unquoted delimiter splits an earlier field such as an address.
+func (s *shaper) looksShifted(row []string) bool {
+	extra := len(row) - len(s.headers)
+	inPlace, shifted := 0, 0
+	for _, c := range s.typed {
+		if c.valid(row[c.idx]) {
+			inPlace++
+		}
+		if c.valid(row[c.idx+extra]) {
+			shifted++
+		}
+	}
+	return shifted > inPlace
+}
+
+// ShapeStats describes the field counts found in the source file. Blank
+// cells past the header are not counted.
+type ShapeStats struct {
+	Width   int          // fields in the header
+	Policy  RaggedPolicy // what was done with ragged rows
+	Counts  map[int]int  // source rows by field count
+	Shifted []int        // lines of long rows that look shifted right
+}
+
+// maxShiftedLines caps how many shifted line numbers Lines lists.
+const maxShiftedLines = 10
+
+// Lines summarizes ragged rows for the output window, or returns nil when
+// every row matched the header.
+func (st ShapeStats) Lines() []string {
+	if len(st.Counts) == 0 || (len(st.Counts) == 1 && st.Counts[st.Width] > 0) {
+		return nil
+	}
+	lines := []string{fmt.Sprintf("Ragged rows (header has %d columns, policy %s):", st.Width, st.Policy)}
+	for _, n := range slices.Sorted(maps.Keys(st.Counts)) {
+		note := ""
+		switch {
+		case n < st.Width:
+			note = " (short)"
+		case n > st.Width:
+			note = " (long)"
+		}
+		lines = append(lines, fmt.Sprintf("  %3d columns: %d rows%s", n, st.Counts[n], note))
+	}
+	if len(st.Shifted) > 0 {
+		var shown []string
+		for _, line := range st.Shifted[:min(len(st.Shifted), maxShiftedLines)] {
+			shown = append(shown, strconv.Itoa(line))
+		}
+		if len(st.Shifted) > maxShiftedLines {
+			shown = append(shown, "...")
+		}
+		lines = append(lines, fmt.Sprintf("  %d rows look shifted right by an unquoted delimiter (lines %s)", len(st.Shifted), strings.Join(shown, ", ")))
+	}
+	return lines
+}
+
+func looksLikeState(v string) bool {
+	v = strings.TrimSpace(v)
+	return len(v) == 2 && unicode.IsLetter(rune(v[0])) && unicode.IsLetter(rune(v[1]))
+}
+
+func looksLikeZip(v string) bool {
+	v, _, _ = strings.Cut(strings.TrimSpace(v), "-")
+	return len(v) == 5 && strings.Trim(v, "0123456789") == ""
+}
+
+func looksLikePhone(v string) bool {
+	digits := 0
+	for _, r := range v {
+		if unicode.IsDigit(r) {
+			digits++
+		} else if unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return digits == 10 || digits == 11
+}
@@ -1,12 +1,9 @@
 package extract
 
 import (
-	"encoding/csv"
-	"fmt"
-	"io"
-	"os"
-	"path/filepath"
 	"strings"
+
+	"etl_go/types"
 )
 
 // DataSet represents the standardized structure returned from the extract phase.
@@ -14,59 +11,54 @@ import (
 type DataSet struct {
 	Headers []string
 	Rows    [][]string
-	Source  string // file name or path
-}
-
-// ReadCSV opens a CSV file, reads all rows, and returns a DataSet.
-// It trims whitespace, ignores blank lines, and safely handles quoted fields.
-func ReadCSV(path string) (*DataSet, error) {
-	// --- Open file ---
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open CSV file: %w", err)
-	}
-	defer f.Close()
+	Lines   []int           // source line of each row, parallel to Rows; nil if unknown
+	Source  string          // file name or path
+	Path    string          // file the data was read from, for reloading
+	Mapping map[Role]string // explicit role -> header assignments, see Schema
+	Format  string          // how a CSV file was decoded, e.g. "tab-delimited, windows-1252"
 
-	// --- Initialize reader ---
-	reader := csv.NewReader(f)
-	reader.FieldsPerRecord = -1 // allow variable-length rows
-	reader.TrimLeadingSpace = true
-	reader.LazyQuotes = true
+	Sheets        []string // worksheets the rows came from, for .xlsx input
+	SkippedSheets []string // worksheets left out of a merge, see ReadOptions
+	SheetStarts   []int    // for a merge, the line each of Sheets' header is numbered
 
-	// --- Read all rows ---
-	rawRows, err := reader.ReadAll()
-	if err != nil {
-		// If it’s an unexpected EOF, try reading line by line instead
-		if err == io.EOF {
-			return nil, fmt.Errorf("unexpected end of file while reading CSV: %w", err)
-		}
-		return nil, fmt.Errorf("error reading CSV: %w", err)
-	}
+	Quarantined []Quarantined // CSV lines that could not be parsed, in file order
+	Shape       ShapeStats    // field counts of the source rows, see ReadOptions.Ragged
+	// Rejects holds rows the ragged-row policy dropped while reading. It is
+	// not carried over by WithRows; newSession moves it into the session.
+	Rejects []types.Reject
+}
 
-	if len(rawRows) == 0 {
-		return nil, fmt.Errorf("CSV file is empty: %s", path)
+// Line returns the source line number of row i. Without recorded line numbers
+// it assumes one header line and no skipped lines.
+func (ds *DataSet) Line(i int) int {
+	if i < len(ds.Lines) {
+		return ds.Lines[i]
 	}
+	return i + 2
+}
 
-	// --- Extract headers ---
-	headers := cleanRow(rawRows[0])
-
-	// --- Process data rows ---
-	var rows [][]string
-	for _, r := range rawRows[1:] {
-		// Skip completely empty lines
-		if len(strings.TrimSpace(strings.Join(r, ""))) == 0 {
-			continue
-		}
-		rows = append(rows, cleanRow(r))
+// ReadCSV opens a CSV file, reads all rows, and returns a DataSet.
+// It reads record by record, trims whitespace, ignores blank lines, and
+// quarantines lines it cannot parse rather than rejecting the whole file.
+// The delimiter, encoding and any BOM are detected unless opts sets them.
+// Use OpenCSV instead when the file is too large to hold in memory.
+func ReadCSV(path string, opts ReadOptions) (*DataSet, error) {
+	src, err := OpenCSV(path, opts)
+	if err != nil {
+		return nil, err
 	}
+	defer src.Close()
 
-	data := &DataSet{
-		Headers: headers,
-		Rows:    rows,
-		Source:  filepath.Base(path),
+	ds, err := Collect(src)
+	if err != nil {
+		return nil, err
 	}
-
-	return data, nil
+	ds.Path = path
+	ds.Format = src.Format().String()
+	ds.Quarantined = src.Quarantined()
+	ds.Shape = src.Shape()
+	ds.Rejects = src.Rejects()
+	return ds, nil
 }
 
 // cleanRow trims whitespace and normalizes cell contents in a row.
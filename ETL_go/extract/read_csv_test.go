@@ -0,0 +1,156 @@
+package extract
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestReadCSVDetectsFormat(t *testing.T) {
+	utf16le, _ := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().String("name\tcity\nJosé\tSan Juan\n")
+	cp1252, _ := charmap.Windows1252.NewEncoder().String("name;city\nJosé;\"Miami; FL\"\n")
+
+	tests := []struct {
+		name, data string
+		opts       ReadOptions
+		format     string
+		headers    []string
+		row        []string
+	}{
+		{"comma", "name,city\nJosé,Miami\n", ReadOptions{}, "comma-delimited, utf-8", []string{"name", "city"}, []string{"José", "Miami"}},
+		{"bom", "\ufeffname,city\nJosé,Miami\n", ReadOptions{}, "comma-delimited, utf-8 with BOM", []string{"name", "city"}, []string{"José", "Miami"}},
+		{"pipe", "name|city|note\nJosé|Miami|\"a, b\"\n", ReadOptions{}, "pipe-delimited, utf-8", []string{"name", "city", "note"}, []string{"José", "Miami", "a, b"}},
+		{"windows-1252", cp1252, ReadOptions{}, "semicolon-delimited, windows-1252", []string{"name", "city"}, []string{"José", "Miami; FL"}},
+		{"utf-16", utf16le, ReadOptions{}, "tab-delimited, utf-16le with BOM", []string{"name", "city"}, []string{"José", "San Juan"}},
+		{"override", "name,city|x\nJosé,Miami|y\n", ReadOptions{Delimiter: "|"}, "pipe-delimited, utf-8", []string{"name,city", "x"}, []string{"José,Miami", "y"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "leads.csv")
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			ds, err := ReadCSV(path, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ds.Format != tt.format {
+				t.Errorf("Format = %q, want %q", ds.Format, tt.format)
+			}
+			if !slices.Equal(ds.Headers, tt.headers) {
+				t.Errorf("Headers = %q, want %q", ds.Headers, tt.headers)
+			}
+			if len(ds.Rows) != 1 || !slices.Equal(ds.Rows[0], tt.row) {
+				t.Errorf("Rows = %q, want [%q]", ds.Rows, tt.row)
+			}
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "leads.csv")
+	os.WriteFile(path, []byte("a,b\n"), 0o644)
+	if _, err := ReadCSV(path, ReadOptions{Encoding: "ebcdic"}); err == nil {
+		t.Error("expected an error for an unknown encoding")
+	}
+}
+
+func TestReadCSVQuarantinesBadLines(t *testing.T) {
+	data := "name,note,phone\n" +
+		"Ann,\"spans\ntwo lines\",5551234567\n" +
+		"Bob,\"never closed,5559876543\n" +
+		"Cy,5\" tall,5550001111\n" +
+		"Dee,bin\x00ary,5552223333\n" +
+		"\n" +
+		"Eve,\"say \"\"hi\"\"\",5554445555\n"
+	path := filepath.Join(t.TempDir(), "leads.csv")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ds, err := ReadCSV(path, ReadOptions{Delimiter: ","})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"Ann", "spans\ntwo lines", "5551234567"},
+		{"Cy", "5\" tall", "5550001111"},
+		{"Eve", "say \"hi\"", "5554445555"},
+	}
+	if !slices.EqualFunc(ds.Rows, want, slices.Equal) {
+		t.Errorf("Rows = %q, want %q", ds.Rows, want)
+	}
+	if wantLines := []int{2, 5, 8}; !slices.Equal(ds.Lines, wantLines) {
+		t.Errorf("Lines = %v, want %v", ds.Lines, wantLines)
+	}
+
+	wantQ := []Quarantined{
+		{Line: 4, Problem: ProblemUnterminatedQuote, Raw: "Bob,\"never closed,5559876543"},
+		{Line: 6, Problem: ProblemBinary, Raw: "Dee,bin\x00ary,5552223333"},
+	}
+	if !slices.Equal(ds.Quarantined, wantQ) {
+		t.Errorf("Quarantined = %+v, want %+v", ds.Quarantined, wantQ)
+	}
+}
+
+func TestReadCSVRaggedRows(t *testing.T) {
+	data := "first,address,city,state,zip,phone\n" +
+		"Ann,1 Main St,Miami,FL,33101,3055551234\n" +
+		"Bob,2 Oak Ave\n" +
+		"Cy,3 Elm St, Apt 4,Tampa,FL,33601,8135551234\n" +
+		"Dee,4 Pine Rd,Orlando,FL,32801,4075551234,,\n"
+	path := filepath.Join(t.TempDir(), "leads.csv")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		policy  RaggedPolicy
+		lines   []int
+		rejects []string
+	}{
+		{RaggedPad, []int{2, 3, 5}, []string{"malformed_row:7_columns"}},
+		{RaggedTruncate, []int{2, 3, 4, 5}, nil},
+		{RaggedReject, []int{2, 5}, []string{"malformed_row:2_columns", "malformed_row:7_columns"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			ds, err := ReadCSV(path, ReadOptions{Ragged: tt.policy})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(ds.Lines, tt.lines) {
+				t.Errorf("Lines = %v, want %v", ds.Lines, tt.lines)
+			}
+			for _, row := range ds.Rows {
+				if len(row) != len(ds.Headers) {
+					t.Errorf("row %q has %d fields, want %d", row, len(row), len(ds.Headers))
+				}
+			}
+			var reasons []string
+			for _, r := range ds.Rejects {
+				reasons = append(reasons, r.Reason)
+			}
+			if !slices.Equal(reasons, tt.rejects) {
+				t.Errorf("rejects = %v, want %v", reasons, tt.rejects)
+			}
+
+			wantCounts := map[int]int{6: 2, 2: 1, 7: 1}
+			if !maps.Equal(ds.Shape.Counts, wantCounts) {
+				t.Errorf("Counts = %v, want %v", ds.Shape.Counts, wantCounts)
+			}
+			if !slices.Equal(ds.Shape.Shifted, []int{4}) {
+				t.Errorf("Shifted = %v, want [4]", ds.Shape.Shifted)
+			}
+		})
+	}
+
+	if _, err := ReadCSV(path, ReadOptions{Ragged: "squash"}); err == nil {
+		t.Error("expected an error for an unknown ragged row policy")
+	}
+}
@@ -3,14 +3,50 @@ package extract
 import (
 	"fmt"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/xuri/excelize/v2"
 )
 
-// ReadXLSX opens a .xlsx file, validates worksheet count,
-// and converts the data to a DataSet compatible with CSV reads.
-func ReadXLSX(path string) (*DataSet, error) {
+// SheetColumn is the column MergeSheets adds to record each row's sheet.
+const SheetColumn = "source_sheet"
+
+// ReadOptions selects what to read from an input file.
+type ReadOptions struct {
+	// Sheet picks one worksheet by name or 0-based index. When empty, the
+	// first sheet with data is used.
+	Sheet string
+	// MergeSheets stacks worksheets that share a header into one DataSet.
+	// "all" takes every sheet; otherwise it lists sheet names or indexes.
+	MergeSheets []string
+
+	// Delimiter forces the CSV field separator: ",", "tab", "|", ";" or any
+	// single character. Empty or "auto" sniffs it from the first lines.
+	Delimiter string
+	// Encoding forces the CSV text encoding: utf-8, windows-1252, latin-1,
+	// utf-16le or utf-16be. Empty or "auto" detects it from the BOM and bytes.
+	Encoding string
+	// Ragged decides what happens to rows with more or fewer fields than
+	// the header. Empty means RaggedPad.
+	Ragged RaggedPolicy
+}
+
+// ListSheets returns the worksheet names of an .xlsx file in workbook order.
+func ListSheets(path string) ([]string, error) {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open xlsx file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	return f.GetSheetList(), nil
+}
+
+// ReadXLSX opens a .xlsx file and converts one worksheet, or several merged
+// ones, to a DataSet compatible with CSV reads.
+func ReadXLSX(path string, opts ReadOptions) (*DataSet, error) {
 	// --- Open the workbook ---
 	f, err := excelize.OpenFile(path)
 	if err != nil {
@@ -18,18 +54,73 @@ func ReadXLSX(path string) (*DataSet, error) {
 	}
 	defer func() { _ = f.Close() }()
 
-	// --- Validate sheet count ---
 	sheets := f.GetSheetList()
 	if len(sheets) == 0 {
 		return nil, fmt.Errorf("no worksheets found in file: %s", path)
 	}
-	if len(sheets) > 1 {
-		return nil, fmt.Errorf("multiple worksheets detected (%d). Please ensure only one worksheet", len(sheets))
+
+	policy, err := ParseRaggedPolicy(string(opts.Ragged))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opts.MergeSheets) > 0 {
+		return mergeSheets(f, path, sheets, opts.MergeSheets, policy)
+	}
+
+	var sheet string
+	if opts.Sheet != "" {
+		if sheet, err = resolveSheet(sheets, opts.Sheet); err != nil {
+			return nil, err
+		}
+	} else if sheet, err = firstSheetWithData(f, sheets); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
 	}
 
-	sheet := sheets[0]
+	data, err := readSheet(f, sheet, policy)
+	if err != nil {
+		return nil, err
+	}
+	data.Path = path
+	data.Source = fmt.Sprintf("%s (sheet: %s)", filepath.Base(path), sheet)
+	data.Sheets = []string{sheet}
+	return data, nil
+}
+
+// resolveSheet finds a sheet by exact name, case-insensitive name or index.
+func resolveSheet(sheets []string, want string) (string, error) {
+	if slices.Contains(sheets, want) {
+		return want, nil
+	}
+	for _, s := range sheets {
+		if strings.EqualFold(s, want) {
+			return s, nil
+		}
+	}
+	if i, err := strconv.Atoi(want); err == nil && i >= 0 && i < len(sheets) {
+		return sheets[i], nil
+	}
+	return "", fmt.Errorf("no worksheet %q; sheets are %s", want, strings.Join(sheets, ", "))
+}
+
+// firstSheetWithData returns the first sheet that has a header and at least
+// one data row, so an instructions tab in front of the leads is skipped.
+func firstSheetWithData(f *excelize.File, sheets []string) (string, error) {
+	for _, s := range sheets {
+		rows, err := f.GetRows(s)
+		if err != nil {
+			return "", fmt.Errorf("failed to read rows from %s: %w", s, err)
+		}
+		if len(rows) > 1 {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("all %d worksheets are empty", len(sheets))
+}
 
-	// --- Read all rows from the single sheet ---
+// readSheet converts one worksheet to a DataSet with spreadsheet row numbers
+// as line numbers. Rows wider than the header are handled by policy.
+func readSheet(f *excelize.File, sheet string, policy RaggedPolicy) (*DataSet, error) {
 	rows, err := f.GetRows(sheet)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read rows from %s: %w", sheet, err)
@@ -40,23 +131,122 @@ func ReadXLSX(path string) (*DataSet, error) {
 
 	// --- Clean and normalize rows ---
 	headers := cleanRow(rows[0])
+	shape := newShaper(headers, policy)
 	var dataRows [][]string
+	var lines []int
 
-	for _, row := range rows[1:] {
+	for i, row := range rows[1:] {
 		// Skip empty rows (Excel sometimes has trailing blanks)
 		if isRowEmpty(row) {
 			continue
 		}
-		dataRows = append(dataRows, cleanRow(row))
+		line := i + 2 // spreadsheet row number
+		row = cleanRow(row)
+		if len(row) < len(headers) {
+			// Excel leaves out trailing empty cells, so a short row is
+			// never really missing fields
+			row = append(row, make([]string, len(headers)-len(row))...)
+		}
+		row, ok := shape.shape(line, row)
+		if !ok {
+			continue
+		}
+		dataRows = append(dataRows, row)
+		lines = append(lines, line)
 	}
 
-	data := &DataSet{
-		Headers: headers,
-		Rows:    dataRows,
-		Source:  fmt.Sprintf("%s (sheet: %s)", filepath.Base(path), sheet),
+	return &DataSet{Headers: headers, Rows: dataRows, Lines: lines, Shape: shape.stats, Rejects: shape.rejects}, nil
+}
+
+// mergeSheets stacks the selected sheets whose header matches the first
+// non-empty one and appends a source_sheet column. Empty sheets and sheets
+// with a different header are listed in SkippedSheets. Line numbers run on
+// from one sheet to the next, as if the sheets were one below the other,
+// so each line names one row of the workbook; SheetStarts records where.
+func mergeSheets(f *excelize.File, path string, sheets, selected []string, policy RaggedPolicy) (*DataSet, error) {
+	var names []string
+	if len(selected) == 1 && strings.EqualFold(selected[0], "all") {
+		names = sheets
+	} else {
+		for _, want := range selected {
+			s, err := resolveSheet(sheets, want)
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, s)
+		}
 	}
 
-	return data, nil
+	var merged *DataSet
+	var used, skipped []string
+	offset := 0 // lines taken by the sheets merged so far
+	for _, s := range names {
+		ds, err := readSheet(f, s, policy)
+		if err != nil || len(ds.Rows) == 0 {
+			skipped = append(skipped, s)
+			continue
+		}
+		if merged == nil {
+			merged = &DataSet{
+				Headers: append(slices.Clone(ds.Headers), SheetColumn),
+				Path:    path,
+				Shape:   ShapeStats{Width: len(ds.Headers), Policy: policy, Counts: make(map[int]int)},
+			}
+		} else if !sameHeaders(merged.Headers[:len(merged.Headers)-1], ds.Headers) {
+			skipped = append(skipped, s)
+			continue
+		}
+		last := renumber(ds, offset)
+		merged.SheetStarts = append(merged.SheetStarts, offset+1)
+		offset = last
+		for n, c := range ds.Shape.Counts {
+			merged.Shape.Counts[n] += c
+		}
+		merged.Shape.Shifted = append(merged.Shape.Shifted, ds.Shape.Shifted...)
+		merged.Rejects = append(merged.Rejects, ds.Rejects...)
+		for i, row := range ds.Rows {
+			// Pad short rows so the sheet name lands in its own column
+			full := make([]string, len(merged.Headers))
+			copy(full[:len(full)-1], row)
+			full[len(full)-1] = s
+			merged.Rows = append(merged.Rows, full)
+			merged.Lines = append(merged.Lines, ds.Lines[i])
+		}
+		used = append(used, s)
+	}
+	if merged == nil {
+		return nil, fmt.Errorf("no worksheet with data among %s", strings.Join(names, ", "))
+	}
+
+	merged.Source = fmt.Sprintf("%s (sheets: %s)", filepath.Base(path), strings.Join(used, ", "))
+	merged.Sheets = used
+	merged.SkippedSheets = skipped
+	return merged, nil
+}
+
+// renumber adds offset to the line numbers of a sheet's rows, ragged-row
+// rejects and shifted rows, returning the sheet's last line.
+func renumber(ds *DataSet, offset int) int {
+	last := offset + 1 // the header
+	for i := range ds.Lines {
+		ds.Lines[i] += offset
+		last = max(last, ds.Lines[i])
+	}
+	for i := range ds.Rejects {
+		ds.Rejects[i].Line += offset
+		last = max(last, ds.Rejects[i].Line)
+	}
+	for i := range ds.Shape.Shifted {
+		ds.Shape.Shifted[i] += offset
+	}
+	return last
+}
+
+// sameHeaders compares header rows ignoring case, spacing and punctuation.
+func sameHeaders(a, b []string) bool {
+	return slices.EqualFunc(a, b, func(x, y string) bool {
+		return normalizeHeader(x) == normalizeHeader(y)
+	})
 }
 
 // isRowEmpty checks if a row contains only empty cells.
@@ -68,3 +258,15 @@ func isRowEmpty(row []string) bool {
 	}
 	return true
 }
+
+// ReadFile loads a .csv or .xlsx file, picking the reader from the extension.
+func ReadFile(path string, opts ReadOptions) (*DataSet, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		return ReadCSV(path, opts)
+	case ".xlsx":
+		return ReadXLSX(path, opts)
+	default:
+		return nil, fmt.Errorf("unsupported file type: %s", ext)
+	}
+}
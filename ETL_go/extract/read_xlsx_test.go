@@ -0,0 +1,81 @@
+package extract
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// writeWorkbook saves sheets (in order) to a temporary .xlsx file.
+func writeWorkbook(t *testing.T, sheets []string, data map[string][][]string) string {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+
+	for i, name := range sheets {
+		if i == 0 {
+			f.SetSheetName("Sheet1", name)
+		} else if _, err := f.NewSheet(name); err != nil {
+			t.Fatal(err)
+		}
+		for r, row := range data[name] {
+			cell, _ := excelize.CoordinatesToCellName(1, r+1)
+			if err := f.SetSheetRow(name, cell, &row); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "leads.xlsx")
+	if err := f.SaveAs(path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadXLSXSheets(t *testing.T) {
+	path := writeWorkbook(t, []string{"Instructions", "FL", "GA", "Notes"}, map[string][][]string{
+		"Instructions": {{"Leads are on the state tabs"}},
+		"FL":           {{"First", "Phone"}, {"Ann", "8135551111"}, {"Bob", "8135552222"}},
+		"GA":           {{"first", "PHONE"}, {"Cal", "4045553333"}},
+		"Notes":        {{"Note"}, {"call after 5"}},
+	})
+
+	ds, err := ReadXLSX(path, ReadOptions{})
+	if err != nil {
+		t.Fatalf("default sheet: %v", err)
+	}
+	if !slices.Equal(ds.Sheets, []string{"FL"}) || len(ds.Rows) != 2 {
+		t.Errorf("expected the FL sheet to be picked, got %v with %d rows", ds.Sheets, len(ds.Rows))
+	}
+
+	for _, want := range []string{"GA", "ga", "2"} {
+		ds, err := ReadXLSX(path, ReadOptions{Sheet: want})
+		if err != nil || ds.Rows[0][0] != "Cal" {
+			t.Errorf("sheet %q: got %v, %v", want, ds, err)
+		}
+	}
+	if _, err := ReadXLSX(path, ReadOptions{Sheet: "TX"}); err == nil {
+		t.Error("expected an error for a missing sheet")
+	}
+
+	ds, err = ReadXLSX(path, ReadOptions{MergeSheets: []string{"all"}})
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if !slices.Equal(ds.Headers, []string{"First", "Phone", SheetColumn}) {
+		t.Errorf("unexpected merged headers %v", ds.Headers)
+	}
+	// GA's lines follow on from FL's two rows, so they don't repeat FL's
+	if len(ds.Rows) != 3 || ds.Rows[2][2] != "GA" || !slices.Equal(ds.Lines, []int{2, 3, 5}) {
+		t.Errorf("unexpected merged rows %v, lines %v", ds.Rows, ds.Lines)
+	}
+	if !slices.Equal(ds.SheetStarts, []int{1, 4}) {
+		t.Errorf("unexpected sheet starts %v", ds.SheetStarts)
+	}
+	if !slices.Equal(ds.SkippedSheets, []string{"Instructions", "Notes"}) {
+		t.Errorf("unexpected skipped sheets %v", ds.SkippedSheets)
+	}
+}
@@ -0,0 +1,202 @@
+package extract
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// maxRecordLines is how many physical lines a quoted field may span before
+// its opening quote is taken to be a stray one and the line is quarantined.
+const maxRecordLines = 100
+
+// Problems recorded for quarantined lines.
+const (
+	ProblemUnterminatedQuote = "unterminated quoted field"
+	ProblemBinary            = "contains NUL bytes"
+)
+
+// Quarantined is a source line that could not be parsed as a CSV record. It
+// is set aside with its raw text so the rest of the file can still be read.
+type Quarantined struct {
+	Line    int    `json:"line"`
+	Problem string `json:"problem"`
+	Raw     string `json:"raw"`
+}
+
+// physLine is one line of the decoded file, terminator included.
+type physLine struct {
+	num  int
+	text string
+}
+
+// recordReader splits delimited text into records one line at a time, with
+// the same rules encoding/csv applies under LazyQuotes and TrimLeadingSpace.
+// Unlike csv.Reader it never gives up on the file: a line that cannot start a
+// record is quarantined and reading resumes on the line after it.
+type recordReader struct {
+	r       *bufio.Reader
+	comma   rune
+	next    int        // number of the next line read from r
+	pending []physLine // lines read ahead but not yet used
+	err     error      // sticky read error, io.EOF at the end
+	width   int        // fields in the header, once it has been read
+
+	line        int // first line of the record Read returned last
+	quarantined []Quarantined
+}
+
+func newRecordReader(r io.Reader, comma rune) *recordReader {
+	return &recordReader{r: bufio.NewReader(r), comma: comma, next: 1}
+}
+
+// readLine returns the next physical line, or false at the end of input.
+func (rr *recordReader) readLine() (physLine, bool) {
+	if len(rr.pending) > 0 {
+		l := rr.pending[0]
+		rr.pending = rr.pending[1:]
+		return l, true
+	}
+	if rr.err != nil {
+		return physLine{}, false
+	}
+	text, err := rr.r.ReadString('\n')
+	if err != nil {
+		rr.err = err
+		if text == "" {
+			return physLine{}, false
+		}
+	}
+	l := physLine{num: rr.next, text: text}
+	rr.next++
+	return l, true
+}
+
+// Read returns the fields of the next non-blank record. It returns io.EOF
+// at the end of input, or the underlying read error.
+func (rr *recordReader) Read() ([]string, error) {
+	for {
+		first, ok := rr.readLine()
+		if !ok {
+			return nil, rr.err
+		}
+		if strings.TrimSpace(first.text) == "" {
+			continue
+		}
+		if strings.ContainsRune(first.text, 0) {
+			rr.quarantine(first, ProblemBinary)
+			continue
+		}
+
+		lines := []physLine{first}
+		text := first.text
+		for {
+			fields, closed := splitRecord(text, rr.comma)
+			if closed {
+				rr.line = first.num
+				return fields, nil
+			}
+			more, ok := rr.readLine()
+			if !ok || len(lines) == maxRecordLines || rr.looksLikeRecord(more) {
+				if ok {
+					lines = append(lines, more)
+				}
+				break
+			}
+			lines = append(lines, more)
+			text += more.text
+		}
+
+		// The quote never closed: set the first line aside and try again
+		// from the one after it
+		rr.quarantine(first, ProblemUnterminatedQuote)
+		rr.pending = append(lines[1:len(lines):len(lines)], rr.pending...)
+	}
+}
+
+// looksLikeRecord reports whether l parses on its own into a full row. Such a
+// line is taken to start the next record rather than continue a quoted field,
+// so one stray quote can't swallow the rows after it.
+func (rr *recordReader) looksLikeRecord(l physLine) bool {
+	if rr.width == 0 {
+		return false
+	}
+	fields, closed := splitRecord(l.text, rr.comma)
+	return closed && len(fields) == rr.width
+}
+
+func (rr *recordReader) quarantine(l physLine, problem string) {
+	rr.quarantined = append(rr.quarantined, Quarantined{
+		Line:    l.num,
+		Problem: problem,
+		Raw:     strings.TrimRight(l.text, "\r\n"),
+	})
+}
+
+// splitRecord splits one record's text into fields. closed is false when
+// the text ends inside a quoted field, meaning the record continues on the
+// next line.
+//
+// Quoting follows encoding/csv with LazyQuotes: a field is quoted only when
+// its first non-space character is a quote, "" inside it is a literal quote,
+// and any other quote not followed by the delimiter or the end of the line
+// is kept as text.
+func splitRecord(text string, comma rune) (fields []string, closed bool) {
+	text = strings.TrimSuffix(text, "\n")
+	text = strings.TrimSuffix(text, "\r")
+	rs := []rune(text)
+
+	i := 0
+	for {
+		// Leading space before a field, as TrimLeadingSpace does
+		for i < len(rs) && rs[i] != comma && unicode.IsSpace(rs[i]) {
+			i++
+		}
+
+		var field string
+		if i < len(rs) && rs[i] == '"' {
+			var ok bool
+			if field, i, ok = quotedField(rs, i+1, comma); !ok {
+				return nil, false
+			}
+		} else {
+			start := i
+			for i < len(rs) && rs[i] != comma {
+				i++
+			}
+			field = string(rs[start:i])
+		}
+
+		fields = append(fields, field)
+		if i >= len(rs) {
+			return fields, true
+		}
+		i++ // the delimiter
+	}
+}
+
+// quotedField reads a quoted field starting just after its opening quote and
+// returns its value and the index after the closing quote. ok is false when
+// the text ends before the field is closed.
+func quotedField(rs []rune, i int, comma rune) (string, int, bool) {
+	var b strings.Builder
+	for i < len(rs) {
+		r := rs[i]
+		i++
+		switch {
+		case r == '\r' && i < len(rs) && rs[i] == '\n':
+			// CRLF inside a quoted field becomes LF
+		case r != '"':
+			b.WriteRune(r)
+		case i < len(rs) && rs[i] == '"':
+			b.WriteRune('"')
+			i++
+		case i >= len(rs) || rs[i] == comma:
+			return b.String(), i, true
+		default:
+			b.WriteRune('"') // lazy: stray quote inside a quoted field
+		}
+	}
+	return "", i, false
+}
@@ -0,0 +1,204 @@
+package extract
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Role names what a column means, independent of where it sits in the header row.
+type Role string
+
+const (
+	RoleSourceID   Role = "source_id"
+	RoleFirstName  Role = "first_name"
+	RoleMiddleName Role = "middle_name"
+	RoleLastName   Role = "last_name"
+	RoleAddress1   Role = "address1"
+	RoleCity       Role = "city"
+	RoleState      Role = "state"
+	RoleZip        Role = "zip"
+	RolePhone      Role = "phone"
+	RoleAddress3   Role = "address3"
+	RoleProvince   Role = "province"
+	RoleEmail      Role = "email"
+	RoleTrustedURL Role = "trusted_url"
+	RoleTimezone   Role = "timezone"
+)
+
+// Roles lists every known role in the order of the standard 13-column schema,
+// followed by optional columns that schema doesn't carry.
+var Roles = []Role{
+	RoleSourceID, RoleFirstName, RoleMiddleName, RoleLastName, RoleAddress1, RoleCity,
+	RoleState, RoleZip, RolePhone, RoleAddress3, RoleProvince, RoleEmail, RoleTrustedURL,
+	RoleTimezone,
+}
+
+// roleAliases holds the normalized header names (see normalizeHeader) each role answers to.
+// The first match in header order wins.
+var roleAliases = map[Role][]string{
+	RoleSourceID:   {"sourceid", "source", "vendorleadcode", "leadid", "id"},
+	RoleFirstName:  {"firstname", "first", "fname", "givenname"},
+	RoleMiddleName: {"middlename", "middle", "middleinitial", "mi", "mname"},
+	RoleLastName:   {"lastname", "last", "lname", "surname", "familyname"},
+	RoleAddress1:   {"address1", "address", "addr1", "addr", "addressline1", "street", "streetaddress"},
+	RoleCity:       {"city", "town"},
+	RoleState:      {"state", "st", "statecode"},
+	RoleZip:        {"zip", "zipcode", "postalcode", "postal", "zip5", "postcode"},
+	RolePhone:      {"phone", "phonenumber", "phone1", "telephone", "tel", "homephone", "primaryphone", "mobile", "cell"},
+	RoleAddress3:   {"address3", "addr3", "addressline3"},
+	RoleProvince:   {"province"},
+	RoleEmail:      {"email", "emailaddress", "mail"},
+	RoleTrustedURL: {"trustedurl", "url"},
+	RoleTimezone:   {"timezone", "tz", "timezonename"},
+}
+
+// ParseRole converts user input like "first_name" or "Zip" into a known Role.
+func ParseRole(s string) (Role, error) {
+	want := strings.ToLower(strings.TrimSpace(s))
+	for _, r := range Roles {
+		if string(r) == want {
+			return r, nil
+		}
+	}
+	return "", fmt.Errorf("unknown column role %q", s)
+}
+
+// Schema maps column roles to their positions in one header row.
+type Schema struct {
+	headers []string
+	index   map[Role]int
+}
+
+// NewSchema resolves roles against headers. Overrides map a role to an exact header
+// name and take precedence over the alias table; an override naming a header that
+// is not present leaves the role unresolved rather than guessing.
+func NewSchema(headers []string, overrides map[Role]string) *Schema {
+	s := &Schema{headers: headers, index: make(map[Role]int)}
+	taken := make(map[int]bool)
+
+	for role, name := range overrides {
+		for i, h := range headers {
+			if normalizeHeader(h) == normalizeHeader(name) {
+				s.index[role] = i
+				taken[i] = true
+				break
+			}
+		}
+	}
+
+	for _, role := range Roles {
+		if _, overridden := overrides[role]; overridden {
+			continue
+		}
+		for _, alias := range roleAliases[role] {
+			if i, ok := s.find(alias, taken); ok {
+				s.index[role] = i
+				taken[i] = true
+				break
+			}
+		}
+	}
+
+	return s
+}
+
+// find returns the first untaken header whose normalized name equals alias.
+func (s *Schema) find(alias string, taken map[int]bool) (int, bool) {
+	for i, h := range s.headers {
+		if !taken[i] && normalizeHeader(h) == alias {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Lookup returns the column index for role, or -1 if it could not be resolved.
+func (s *Schema) Lookup(role Role) int {
+	if i, ok := s.index[role]; ok {
+		return i
+	}
+	return -1
+}
+
+// Require returns the column indexes for roles in the same order, or an error
+// naming every role that could not be resolved.
+func (s *Schema) Require(roles ...Role) ([]int, error) {
+	idx := make([]int, len(roles))
+	var missing []string
+	for i, role := range roles {
+		idx[i] = s.Lookup(role)
+		if idx[i] < 0 {
+			missing = append(missing, string(role))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("required column(s) %s not found in headers [%s]; use 'map <role> <column>' to assign them",
+			strings.Join(missing, ", "), strings.Join(s.headers, ", "))
+	}
+	return idx, nil
+}
+
+// Describe returns one line per role showing which header it resolved to.
+func (s *Schema) Describe() []string {
+	var lines []string
+	for _, role := range Roles {
+		i := s.Lookup(role)
+		if i < 0 {
+			lines = append(lines, fmt.Sprintf("  %-12s -> (not found)", role))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("  %-12s -> [%d] %s", role, i, s.headers[i]))
+	}
+	return lines
+}
+
+// Schema resolves column roles for the dataset's current headers.
+func (ds *DataSet) Schema() *Schema {
+	return NewSchema(ds.Headers, ds.Mapping)
+}
+
+// WithRows returns a copy of ds that shares its headers, source and column
+// mapping but holds the given rows and their source line numbers.
+// Transforms use it to build their result.
+func (ds *DataSet) WithRows(rows [][]string, lines []int) *DataSet {
+	return &DataSet{
+		Headers: ds.Headers,
+		Rows:    rows,
+		Lines:   lines,
+		Source:  ds.Source,
+		Path:    ds.Path,
+		Format:  ds.Format,
+		Sheets:  ds.Sheets,
+		Mapping: ds.Mapping,
+
+		SheetStarts: ds.SheetStarts,
+		Quarantined: ds.Quarantined,
+		Shape:       ds.Shape,
+	}
+}
+
+// MapColumn returns a copy of ds whose role is pinned to the given header.
+func (ds *DataSet) MapColumn(role Role, header string) *DataSet {
+	mapping := make(map[Role]string, len(ds.Mapping)+1)
+	for r, h := range ds.Mapping {
+		mapping[r] = h
+	}
+	mapping[role] = header
+
+	out := ds.WithRows(ds.Rows, ds.Lines)
+	out.Mapping = mapping
+	return out
+}
+
+// normalizeHeader lowercases a header and strips everything but letters and digits,
+// so "Phone Number", "phone_number" and "PhoneNumber" compare equal.
+func normalizeHeader(h string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(h) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
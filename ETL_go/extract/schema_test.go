@@ -0,0 +1,38 @@
+package extract
+
+import "testing"
+
+func TestSchemaResolvesAliases(t *testing.T) {
+	headers := []string{"source_id", "first_name", "middle", "last_name", "address1", "city", "state",
+		"postal_code", "phone number", "address3", "province", "email", "Trusted_URL", "Time Zone"}
+	s := NewSchema(headers, nil)
+
+	for i, role := range Roles {
+		if got := s.Lookup(role); got != i {
+			t.Errorf("role %s: expected column %d, got %d", role, i, got)
+		}
+	}
+}
+
+func TestSchemaOverrideWins(t *testing.T) {
+	headers := []string{"Phone", "Cell", "Zip"}
+	s := NewSchema(headers, map[Role]string{RolePhone: "cell"})
+
+	if got := s.Lookup(RolePhone); got != 1 {
+		t.Errorf("expected override to map phone to column 1, got %d", got)
+	}
+	if got := s.Lookup(RoleZip); got != 2 {
+		t.Errorf("expected zip at column 2, got %d", got)
+	}
+}
+
+func TestSchemaRequireNamesMissingRoles(t *testing.T) {
+	s := NewSchema([]string{"First", "Last"}, nil)
+
+	if _, err := s.Require(RoleFirstName, RoleLastName); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := s.Require(RoleFirstName, RolePhone); err == nil {
+		t.Error("expected an error for the missing phone column")
+	}
+}
@@ -0,0 +1,219 @@
+package extract
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"etl_go/types"
+)
+
+// RowSource yields data rows one at a time so large files never have to be
+// held in memory. Next returns io.EOF once every row has been read, and Line
+// reports the source line of the row Next returned last.
+type RowSource interface {
+	Name() string
+	Headers() []string
+	Next() ([]string, error)
+	Line() int
+	Close() error
+}
+
+// CSVSource streams the data rows of a CSV file.
+type CSVSource struct {
+	f       *os.File
+	reader  *recordReader
+	headers []string
+	name    string
+	line    int
+	format  CSVFormat
+	shape   *shaper
+}
+
+// OpenCSV opens a CSV file and reads its header row. Rows are then read on
+// demand with Next, trimmed the same way ReadCSV trims them, and blank lines
+// are skipped. The delimiter and encoding are detected unless opts sets them.
+// A line that cannot be parsed is quarantined instead of failing the read;
+// see Quarantined.
+func OpenCSV(path string, opts ReadOptions) (*CSVSource, error) {
+	policy, err := ParseRaggedPolicy(string(opts.Ragged))
+	if err != nil {
+		return nil, err
+	}
+
+	// --- Open file ---
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV file: %w", err)
+	}
+
+	// --- Detect format ---
+	decoded, format, err := decodeCSV(f, opts)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// --- Initialize reader ---
+	reader := newRecordReader(decoded, format.Delimiter)
+
+	// --- Extract headers ---
+	header, err := reader.Read()
+	if err == io.EOF {
+		f.Close()
+		return nil, fmt.Errorf("CSV file is empty: %s", path)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error reading CSV header: %w", err)
+	}
+	if len(reader.quarantined) > 0 {
+		// Without a header there is nothing to line the rows up against
+		q := reader.quarantined[0]
+		f.Close()
+		return nil, fmt.Errorf("malformed CSV header on line %d: %s", q.Line, q.Problem)
+	}
+	reader.width = len(header)
+
+	headers := cleanRow(header)
+	return &CSVSource{
+		f:       f,
+		reader:  reader,
+		headers: headers,
+		name:    filepath.Base(path),
+		format:  format,
+		shape:   newShaper(headers, policy),
+	}, nil
+}
+
+// Format returns the delimiter and encoding the file was read with.
+func (s *CSVSource) Format() CSVFormat {
+	return s.format
+}
+
+// Name returns the base name of the file being read.
+func (s *CSVSource) Name() string {
+	return s.name
+}
+
+// Headers returns the cleaned header row.
+func (s *CSVSource) Headers() []string {
+	return s.headers
+}
+
+// Next returns the next non-blank data row at the header's width, or io.EOF
+// at the end of the file. Rows the ragged-row policy rejects are skipped and
+// kept for Rejects.
+func (s *CSVSource) Next() ([]string, error) {
+	for {
+		r, err := s.reader.Read()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading CSV: %w", err)
+		}
+
+		// Skip lines holding only delimiters
+		if len(strings.TrimSpace(strings.Join(r, ""))) == 0 {
+			continue
+		}
+		row, ok := s.shape.shape(s.reader.line, cleanRow(r))
+		if !ok {
+			continue
+		}
+		s.line = s.reader.line
+		return row, nil
+	}
+}
+
+// Line returns the line the last row started on; quoted fields can make a
+// row span several lines.
+func (s *CSVSource) Line() int {
+	return s.line
+}
+
+// Quarantined returns the lines set aside so far because they could not be
+// parsed.
+func (s *CSVSource) Quarantined() []Quarantined {
+	return s.reader.quarantined
+}
+
+// Shape returns the field counts of the rows read so far.
+func (s *CSVSource) Shape() ShapeStats {
+	return s.shape.stats
+}
+
+// Rejects returns the rows the ragged-row policy has dropped so far.
+func (s *CSVSource) Rejects() []types.Reject {
+	return s.shape.rejects
+}
+
+// Close closes the underlying file.
+func (s *CSVSource) Close() error {
+	return s.f.Close()
+}
+
+// sliceSource streams the rows of an in-memory DataSet.
+type sliceSource struct {
+	ds  *DataSet
+	pos int
+}
+
+// Stream returns a RowSource over the dataset's rows, so in-memory data can
+// go through the same pipeline as a streamed file.
+func (ds *DataSet) Stream() RowSource {
+	return &sliceSource{ds: ds}
+}
+
+func (s *sliceSource) Name() string {
+	return s.ds.Source
+}
+
+func (s *sliceSource) Headers() []string {
+	return s.ds.Headers
+}
+
+func (s *sliceSource) Next() ([]string, error) {
+	if s.pos >= len(s.ds.Rows) {
+		return nil, io.EOF
+	}
+	row := s.ds.Rows[s.pos]
+	s.pos++
+	return row, nil
+}
+
+func (s *sliceSource) Line() int {
+	return s.ds.Line(s.pos - 1)
+}
+
+func (s *sliceSource) Close() error {
+	return nil
+}
+
+// Collect drains src into a DataSet.
+func Collect(src RowSource) (*DataSet, error) {
+	var rows [][]string
+	var lines []int
+	for {
+		row, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+		lines = append(lines, src.Line())
+	}
+
+	data := &DataSet{
+		Headers: src.Headers(),
+		Rows:    rows,
+		Lines:   lines,
+		Source:  src.Name(),
+	}
+	return data, nil
+}
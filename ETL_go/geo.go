@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+
+	"leadkit/geodata"
+)
+
+// Files update-geo-data looks for when no file is given.
+const (
+	defaultAreaCodeFile = "area_codes.csv"
+	defaultZipFile      = "zips.csv.gz"
+)
+
+// updateGeoData swaps a geodata table for the one in path. kind is
+// "area-codes" or "zips". The current table stays in use if the file can't
+// be read or fails validation.
+func updateGeoData(kind, path string) ([]string, error) {
+	switch kind {
+	case "area-codes":
+		t, err := geodata.LoadAreaCodes(path)
+		if err != nil {
+			return nil, fmt.Errorf("update-geo-data: %w", err)
+		}
+		old := geodata.AreaCodes()
+		geodata.SetAreaCodes(t)
+		return []string{
+			fmt.Sprintf("Loaded %d area codes from %s (version %s, was %s)", t.Len(), path, t.Version, old.Version),
+		}, nil
+
+	case "zips":
+		t, err := geodata.LoadZips(path)
+		if err != nil {
+			return nil, fmt.Errorf("update-geo-data: %w", err)
+		}
+		old := geodata.Zips()
+		geodata.SetZips(t)
+		return []string{
+			fmt.Sprintf("Loaded %d ZIP codes from %s (version %s, was %s)", t.Len(), path, t.Version, old.Version),
+		}, nil
+	}
+	return nil, fmt.Errorf("update-geo-data: unknown table %q (expected area-codes or zips)", kind)
+}
+
+// parseGeoDataArgs reads "update-geo-data [area-codes|zips] [file]".
+func parseGeoDataArgs(args []string) (kind, path string) {
+	kind = "area-codes"
+	if len(args) > 0 && (args[0] == "area-codes" || args[0] == "zips") {
+		kind, args = args[0], args[1:]
+	}
+	path = defaultAreaCodeFile
+	if kind == "zips" {
+		path = defaultZipFile
+	}
+	if len(args) > 0 {
+		path = args[0]
+	}
+	return kind, path
+}
@@ -3,13 +3,20 @@ module etl_go
 go 1.25.1
 
 require (
+	astguiclient v0.0.0
+	github.com/DATA-DOG/go-sqlmock v1.5.2
 	github.com/charmbracelet/bubbletea v1.3.10
 	github.com/charmbracelet/lipgloss v1.1.0
 	github.com/fatih/color v1.18.0
+	github.com/go-sql-driver/mysql v1.10.1
 	github.com/xuri/excelize/v2 v2.9.1
+	golang.org/x/text v0.28.0
+	gopkg.in/yaml.v3 v3.0.1
+	leadkit v0.0.0
 )
 
 require (
+	filippo.io/edwards25519 v1.2.0 // indirect
 	github.com/aymanbagabas/go-osc52/v2 v2.0.1 // indirect
 	github.com/charmbracelet/colorprofile v0.2.3-0.20250311203215-f60798e515dc // indirect
 	github.com/charmbracelet/x/ansi v0.10.1 // indirect
@@ -34,5 +41,9 @@ require (
 	golang.org/x/crypto v0.38.0 // indirect
 	golang.org/x/net v0.40.0 // indirect
 	golang.org/x/sys v0.36.0 // indirect
-	golang.org/x/text v0.28.0 // indirect
+)
+
+replace (
+	astguiclient => ../astguiclient
+	leadkit => ../leadkit
 )
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"etl_go/extract"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	gridIndexStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
+	gridCursorStyle = lipgloss.NewStyle().Reverse(true)
+	gridStatusStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("7"))
+)
+
+// gridChrome is the number of screen lines the grid uses besides rows: the
+// title, column numbers, header, separator and status line.
+const gridChrome = 5
+
+// gridModel is the full-screen table opened by the browse command. The line
+// number column on the left and the header rows stay put while the rest
+// scrolls.
+type gridModel struct {
+	ds            *extract.DataSet
+	width, height int
+	row, col      int // cursor: row and column index into ds
+	top, left     int // first row and first scrolling column on screen
+
+	prompt string // "/" or ":" while typing a search or jump, else ""
+	input  string
+	query  string // last search, for n and N
+	status string
+}
+
+func newGrid(ds *extract.DataSet, width, height int) *gridModel {
+	return &gridModel{ds: ds, width: width, height: height}
+}
+
+// pageRows is the number of data rows that fit on screen.
+func (g *gridModel) pageRows() int {
+	return max(1, g.height-gridChrome)
+}
+
+// lineWidth is the width of the frozen line number column.
+func (g *gridModel) lineWidth() int {
+	if len(g.ds.Rows) == 0 {
+		return 4
+	}
+	return max(4, len(strconv.Itoa(g.ds.Line(len(g.ds.Rows)-1))))
+}
+
+// widths sizes the columns for the rows on screen.
+func (g *gridModel) widths() []int {
+	end := min(g.top+g.pageRows(), len(g.ds.Rows))
+	return g.ds.ColumnWidths(g.top, end, g.width-g.lineWidth()-3)
+}
+
+// visibleColumns returns the scrolling columns that fit on screen,
+// starting at g.left.
+func (g *gridModel) visibleColumns(widths []int) []int {
+	room := g.width - g.lineWidth() - 3
+	var cols []int
+	for j := g.left; j < len(widths); j++ {
+		room -= widths[j] + 3
+		if room < 0 && len(cols) > 0 {
+			break
+		}
+		cols = append(cols, j)
+	}
+	return cols
+}
+
+// clamp keeps the cursor inside the dataset and scrolls it into view.
+func (g *gridModel) clamp() {
+	g.row = max(0, min(g.row, len(g.ds.Rows)-1))
+	g.col = max(0, min(g.col, len(g.ds.Headers)-1))
+	if g.row < g.top {
+		g.top = g.row
+	}
+	if g.row >= g.top+g.pageRows() {
+		g.top = g.row - g.pageRows() + 1
+	}
+	if g.col < g.left {
+		g.left = g.col
+	}
+	widths := g.widths()
+	for g.left < g.col {
+		cols := g.visibleColumns(widths)
+		if cols[len(cols)-1] >= g.col {
+			break
+		}
+		g.left++
+	}
+}
+
+// Update handles a key, returning true when the grid should close.
+func (g *gridModel) Update(msg tea.KeyMsg) bool {
+	key := msg.String()
+	if g.prompt != "" {
+		switch key {
+		case "esc":
+			g.prompt, g.input = "", ""
+		case "enter":
+			g.submit()
+		case "backspace":
+			if len(g.input) > 0 {
+				g.input = g.input[:len(g.input)-1]
+			}
+		default:
+			if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+				g.input += string(msg.Runes)
+			}
+		}
+		return false
+	}
+
+	g.status = ""
+	switch key {
+	case "esc", "q":
+		return true
+	case "up", "k":
+		g.row--
+	case "down", "j":
+		g.row++
+	case "left", "h":
+		g.col--
+	case "right", "l":
+		g.col++
+	case "pgup", "b":
+		g.row -= g.pageRows()
+		g.top -= g.pageRows()
+	case "pgdown", "f", " ":
+		g.row += g.pageRows()
+		g.top += g.pageRows()
+	case "home", "g":
+		g.row = 0
+	case "end", "G":
+		g.row = len(g.ds.Rows) - 1
+	case "0":
+		g.col = 0
+	case "$":
+		g.col = len(g.ds.Headers) - 1
+	case "/", ":":
+		g.prompt, g.input = key, ""
+	case "n":
+		g.search(g.query, true)
+	case "N":
+		g.search(g.query, false)
+	}
+	g.top = max(0, min(g.top, len(g.ds.Rows)-g.pageRows()))
+	g.clamp()
+	return false
+}
+
+// submit runs the search or jump typed at the prompt.
+func (g *gridModel) submit() {
+	prompt, input := g.prompt, strings.TrimSpace(g.input)
+	g.prompt, g.input = "", ""
+	if input == "" {
+		return
+	}
+	if prompt == "/" {
+		g.query = input
+		g.search(input, true)
+	} else {
+		g.jump(input)
+	}
+	g.clamp()
+}
+
+// jump moves to a source line number, or to a column given by index,
+// header or role.
+func (g *gridModel) jump(target string) {
+	if n, err := strconv.Atoi(target); err == nil {
+		// Don't assume lines only go up: scan for the line, or else the
+		// closest one after it
+		next := -1
+		for i := range g.ds.Rows {
+			line := g.ds.Line(i)
+			if line == n {
+				g.row = i
+				return
+			}
+			if line > n && (next < 0 || line < g.ds.Line(next)) {
+				next = i
+			}
+		}
+		if next < 0 {
+			g.status = fmt.Sprintf("No rows after line %d.", n)
+		} else {
+			g.status = fmt.Sprintf("Line %d was removed; showing line %d.", n, g.ds.Line(next))
+			g.row = next
+		}
+		return
+	}
+	if target, ok := strings.CutPrefix(target, "#"); ok {
+		if j, err := strconv.Atoi(target); err == nil && j >= 0 && j < len(g.ds.Headers) {
+			g.col = j
+			return
+		}
+	}
+	for j, h := range g.ds.Headers {
+		if strings.EqualFold(h, target) {
+			g.col = j
+			return
+		}
+	}
+	if role, err := extract.ParseRole(target); err == nil {
+		if j := g.ds.Schema().Lookup(role); j >= 0 {
+			g.col = j
+			return
+		}
+	}
+	g.status = fmt.Sprintf("No line or column %q.", target)
+}
+
+// search moves to the next cell after the cursor containing query, or the
+// previous one when forward is false, wrapping around the ends. Matching
+// ignores case.
+func (g *gridModel) search(query string, forward bool) {
+	if query == "" {
+		g.status = "No search yet; press / to search."
+		return
+	}
+	q := strings.ToLower(query)
+	cols := len(g.ds.Headers)
+	cells := len(g.ds.Rows) * cols
+	step := 1
+	if !forward {
+		step = -1
+	}
+	start := g.row*cols + g.col
+	for n := 1; n <= cells; n++ {
+		c := ((start+step*n)%cells + cells) % cells
+		row := g.ds.Rows[c/cols]
+		if j := c % cols; j < len(row) && strings.Contains(strings.ToLower(row[j]), q) {
+			if c <= start && forward || c >= start && !forward {
+				g.status = "Search wrapped."
+			}
+			g.row, g.col = c/cols, j
+			return
+		}
+	}
+	g.status = fmt.Sprintf("%q not found.", query)
+}
+
+func (g *gridModel) View() string {
+	if len(g.ds.Rows) == 0 || len(g.ds.Headers) == 0 {
+		return "No data loaded. Press Esc to go back."
+	}
+	widths := g.widths()
+	cols := g.visibleColumns(widths)
+	lw := g.lineWidth()
+
+	title := fmt.Sprintf("%s — row %d of %d (line %d), column %d %s",
+		g.ds.Source, g.row+1, len(g.ds.Rows), g.ds.Line(g.row), g.col, g.ds.Headers[g.col])
+	lines := []string{titleStyle.Render(title)}
+
+	index := fmt.Sprintf("%*s |", lw, "")
+	header := fmt.Sprintf("%*s |", lw, "line")
+	sep := strings.Repeat("-", lw+1) + "+"
+	for _, j := range cols {
+		index += fmt.Sprintf(" %-*d |", widths[j], j)
+		header += fmt.Sprintf(" %-*s |", widths[j], extract.FitCell(g.ds.Headers[j], widths[j]))
+		sep += strings.Repeat("-", widths[j]+2) + "+"
+	}
+	lines = append(lines, gridIndexStyle.Render(index), header, sep)
+
+	end := min(g.top+g.pageRows(), len(g.ds.Rows))
+	for i := g.top; i < end; i++ {
+		row := g.ds.Rows[i]
+		line := gridIndexStyle.Render(fmt.Sprintf("%*d", lw, g.ds.Line(i))) + " |"
+		for _, j := range cols {
+			val := ""
+			if j < len(row) {
+				val = row[j]
+			}
+			cell := fmt.Sprintf("%-*s", widths[j], extract.FitCell(val, widths[j]))
+			if i == g.row && j == g.col {
+				cell = gridCursorStyle.Render(cell)
+			}
+			line += " " + cell + " |"
+		}
+		lines = append(lines, line)
+	}
+	for i := end - g.top; i < g.pageRows(); i++ {
+		lines = append(lines, "")
+	}
+
+	status := g.status
+	switch {
+	case g.prompt == "/":
+		status = "Search: " + g.input
+	case g.prompt == ":":
+		status = "Go to line, #column, header or role: " + g.input
+	case status == "":
+		status = "↑↓←→ move · PgUp/PgDn page · g/G first/last · / search, n/N next/prev · : jump · Esc back"
+	}
+	lines = append(lines, gridStatusStyle.Render(status))
+	return strings.Join(lines, "\n")
+}
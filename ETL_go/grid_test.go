@@ -0,0 +1,153 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"etl_go/extract"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// gridData returns n rows whose source lines skip every third line, as if
+// a step had removed them.
+func gridData(n int) *extract.DataSet {
+	ds := &extract.DataSet{
+		Headers: []string{"First", "Last", "Address", "Phone"},
+		Source:  "grid.csv",
+	}
+	line := 2
+	for i := range n {
+		if line%3 == 0 {
+			line++
+		}
+		ds.Rows = append(ds.Rows, []string{"Name" + strconv.Itoa(i), "Last", "1 Main St", "813555" + strconv.Itoa(1000+i)})
+		ds.Lines = append(ds.Lines, line)
+		line++
+	}
+	return ds
+}
+
+func keys(g *gridModel, ks ...string) {
+	for _, k := range ks {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "pgdown":
+			msg = tea.KeyMsg{Type: tea.KeyPgDown}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		g.Update(msg)
+	}
+}
+
+func TestGridPagingKeepsCursorOnScreen(t *testing.T) {
+	g := newGrid(gridData(100), 80, 15) // 10 rows a page
+	keys(g, "pgdown", "pgdown")
+	if g.row != 20 || g.top != 20 {
+		t.Errorf("after two pages: row %d, top %d", g.row, g.top)
+	}
+	keys(g, "G")
+	if g.row != 99 || g.top != 90 {
+		t.Errorf("G: row %d, top %d", g.row, g.top)
+	}
+	keys(g, "g", "k")
+	if g.row != 0 || g.top != 0 {
+		t.Errorf("g then up: row %d, top %d", g.row, g.top)
+	}
+}
+
+func TestGridJump(t *testing.T) {
+	ds := gridData(100)
+	g := newGrid(ds, 80, 15)
+
+	keys(g, ":", "5", "0", "enter")
+	if got := ds.Line(g.row); got != 50 {
+		t.Errorf("jump to line 50 landed on line %d", got)
+	}
+	keys(g, ":", "5", "1", "enter")
+	if got := ds.Line(g.row); got != 52 || !strings.Contains(g.status, "removed") {
+		t.Errorf("jump to removed line 51: line %d, status %q", got, g.status)
+	}
+	keys(g, ":", "phone", "enter")
+	if g.col != 3 {
+		t.Errorf("jump to phone column: col %d", g.col)
+	}
+	keys(g, ":", "#", "1", "enter")
+	if g.col != 1 {
+		t.Errorf("jump to #1: col %d", g.col)
+	}
+}
+
+func TestGridJumpWithUnorderedLines(t *testing.T) {
+	ds := gridData(4)
+	ds.Lines = []int{5, 2, 9, 3}
+	g := newGrid(ds, 80, 15)
+
+	keys(g, ":", "3", "enter")
+	if g.row != 3 {
+		t.Errorf("jump to line 3 landed on row %d", g.row)
+	}
+	keys(g, ":", "4", "enter")
+	if g.row != 0 || !strings.Contains(g.status, "showing line 5") {
+		t.Errorf("jump to missing line 4: row %d, status %q", g.row, g.status)
+	}
+	keys(g, ":", "1", "0", "enter")
+	if g.row != 0 || !strings.Contains(g.status, "No rows after") {
+		t.Errorf("jump past the last line: row %d, status %q", g.row, g.status)
+	}
+}
+
+func TestGridSearchWraps(t *testing.T) {
+	g := newGrid(gridData(30), 80, 15)
+	keys(g, "/", "n", "a", "m", "e", "2", "enter")
+	if g.row != 2 || g.col != 0 {
+		t.Fatalf("first match: row %d col %d", g.row, g.col)
+	}
+	keys(g, "n")
+	if g.row != 20 {
+		t.Errorf("next match: row %d", g.row)
+	}
+	keys(g, "G", "n")
+	if g.row != 2 || g.status != "Search wrapped." {
+		t.Errorf("after wrapping: row %d, status %q", g.row, g.status)
+	}
+	keys(g, "N")
+	if g.row != 29 {
+		t.Errorf("previous match: row %d", g.row)
+	}
+}
+
+func TestGridFreezesLineColumnAndHeader(t *testing.T) {
+	g := newGrid(gridData(100), 24, 15)
+	keys(g, "$", "G")
+	view := g.View()
+	lines := strings.Split(view, "\n")
+	if !strings.Contains(lines[2], "line") || !strings.Contains(lines[2], "Phone") {
+		t.Errorf("header should show the line column and Phone: %q", lines[2])
+	}
+	if strings.Contains(lines[2], "First") {
+		t.Errorf("First should have scrolled off a narrow screen: %q", lines[2])
+	}
+	if !strings.Contains(view, strconv.Itoa(g.ds.Line(99))) {
+		t.Error("last row's line number should be on screen")
+	}
+}
+
+func TestBrowseCommandOpensAndClosesGrid(t *testing.T) {
+	m := testModel()
+	m.width, m.height = 80, 24
+	m = run(m, "browse")
+	if m.grid == nil {
+		t.Fatal("browse should open the grid")
+	}
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if next.(model).grid != nil {
+		t.Error("Esc should close the grid")
+	}
+}
@@ -0,0 +1,194 @@
+package load
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"etl_go/extract"
+	"etl_go/transform"
+	"etl_go/types"
+)
+
+// Scopes for ExistingScope.Kind.
+const (
+	ScopeSystem   = "system"   // every lead in vicidial_list
+	ScopeList     = "list"     // leads in the given lists
+	ScopeCampaign = "campaign" // leads in lists belonging to a campaign
+)
+
+// ExistingScope selects which loaded leads and DNC tables a dedup checks.
+type ExistingScope struct {
+	Kind     string
+	Lists    []int64 // for ScopeList
+	Campaign string  // for ScopeCampaign
+	SkipDNC  bool    // leave out vicidial_dnc and vicidial_campaign_dnc
+}
+
+// ParseExistingScope reads "system", "list=1001,1002" or "campaign=SALES".
+func ParseExistingScope(s string) (ExistingScope, error) {
+	key, val, _ := strings.Cut(s, "=")
+	switch strings.ToLower(key) {
+	case ScopeSystem:
+		return ExistingScope{Kind: ScopeSystem}, nil
+	case ScopeList:
+		scope := ExistingScope{Kind: ScopeList}
+		for _, id := range strings.Split(val, ",") {
+			n, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64)
+			if err != nil {
+				return ExistingScope{}, fmt.Errorf("bad list_id %q", id)
+			}
+			scope.Lists = append(scope.Lists, n)
+		}
+		return scope, nil
+	case ScopeCampaign:
+		if val == "" {
+			return ExistingScope{}, fmt.Errorf("campaign scope needs a campaign_id")
+		}
+		return ExistingScope{Kind: ScopeCampaign, Campaign: val}, nil
+	default:
+		return ExistingScope{}, fmt.Errorf("unknown scope %q (expected system, list=<ids> or campaign=<id>)", s)
+	}
+}
+
+// AddDatabasePhones adds the phones in scope to set: DNC entries first, so a
+// number that is both loaded and DNC is rejected as DNC, then leads already
+// in vicidial_list. It returns how many rows it read.
+func AddDatabasePhones(ctx context.Context, db *sql.DB, scope ExistingScope, set *transform.PhoneSet) (int, error) {
+	type source struct {
+		query  string
+		args   []any
+		reason func(string) string // from the second column, if the query has one
+	}
+	var sources []source
+
+	dnc := func(which string) func(string) string {
+		return func(string) string { return types.ReasonDNC + ":" + which }
+	}
+	loaded := func(list string) string { return types.ReasonExistingLead + ":list_" + list }
+
+	if !scope.SkipDNC {
+		sources = append(sources, source{query: "SELECT phone_number FROM vicidial_dnc", reason: dnc("system")})
+		if scope.Kind == ScopeCampaign {
+			sources = append(sources, source{
+				query:  "SELECT phone_number FROM vicidial_campaign_dnc WHERE campaign_id = ?",
+				args:   []any{scope.Campaign},
+				reason: dnc("campaign_" + scope.Campaign),
+			})
+		}
+	}
+
+	switch scope.Kind {
+	case ScopeSystem:
+		sources = append(sources, source{query: "SELECT phone_number, list_id FROM vicidial_list", reason: loaded})
+	case ScopeList:
+		if len(scope.Lists) == 0 {
+			return 0, fmt.Errorf("list scope needs at least one list_id")
+		}
+		args := make([]any, len(scope.Lists))
+		for i, id := range scope.Lists {
+			args[i] = id
+		}
+		sources = append(sources, source{
+			query:  "SELECT phone_number, list_id FROM vicidial_list WHERE list_id IN (?" + strings.Repeat(", ?", len(args)-1) + ")",
+			args:   args,
+			reason: loaded,
+		})
+	case ScopeCampaign:
+		sources = append(sources, source{
+			query: "SELECT vl.phone_number, vl.list_id FROM vicidial_list vl " +
+				"JOIN vicidial_lists l ON l.list_id = vl.list_id WHERE l.campaign_id = ?",
+			args:   []any{scope.Campaign},
+			reason: loaded,
+		})
+	default:
+		return 0, fmt.Errorf("unknown scope %q", scope.Kind)
+	}
+
+	read := 0
+	for _, src := range sources {
+		n, err := addQueryPhones(ctx, db, src.query, src.args, src.reason, set)
+		read += n
+		if err != nil {
+			return read, err
+		}
+	}
+	return read, nil
+}
+
+// addQueryPhones adds the first column of every row of query to set. A
+// second column, when present, is passed to reason.
+func addQueryPhones(ctx context.Context, db *sql.DB, query string, args []any, reason func(string) string, set *transform.PhoneSet) (int, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("querying phones: %w", err)
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	var phone, extra sql.NullString
+	dest := []any{&phone}
+	if len(cols) > 1 {
+		dest = append(dest, &extra)
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return n, fmt.Errorf("reading phones: %w", err)
+		}
+		set.Add(phone.String, reason(extra.String))
+		n++
+	}
+	return n, rows.Err()
+}
+
+// AddFilePhones adds the phones in a file to set with the given reason code
+// (types.ReasonDNC or types.ReasonExistingLead); the file's base name is the
+// reason's detail. A .csv or .xlsx file, such as an earlier export, is read
+// through its phone column; anything else is read as one number per line.
+func AddFilePhones(path, code string, set *transform.PhoneSet) (int, error) {
+	reason := code + ":" + filepath.Base(path)
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".xlsx":
+		ds, err := extract.ReadFile(path, extract.ReadOptions{})
+		if err != nil {
+			return 0, err
+		}
+		idx := ds.Schema().Lookup(extract.RolePhone)
+		if idx < 0 {
+			return 0, fmt.Errorf("%s: no column has the %s role", path, extract.RolePhone)
+		}
+		for _, row := range ds.Rows {
+			set.Add(row[idx], reason)
+		}
+		return len(ds.Rows), nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	n := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			set.Add(line, reason)
+			n++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return n, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,82 @@
+package load
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"etl_go/transform"
+	"etl_go/types"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestAddDatabasePhonesCampaignScope(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT phone_number FROM vicidial_dnc")).
+		WillReturnRows(sqlmock.NewRows([]string{"phone_number"}).AddRow("8135550001"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT phone_number FROM vicidial_campaign_dnc WHERE campaign_id = ?")).
+		WithArgs("SALES").
+		WillReturnRows(sqlmock.NewRows([]string{"phone_number"}).AddRow("8135550002"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM vicidial_list vl JOIN vicidial_lists l ON l.list_id = vl.list_id WHERE l.campaign_id = ?")).
+		WithArgs("SALES").
+		WillReturnRows(sqlmock.NewRows([]string{"phone_number", "list_id"}).
+			AddRow("8135550001", "1001").
+			AddRow("8135550003", "1002"))
+
+	scope, err := ParseExistingScope("campaign=SALES")
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := transform.NewPhoneSet()
+	n, err := AddDatabasePhones(context.Background(), db, scope, set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 || set.Len() != 3 {
+		t.Errorf("read %d rows into %d phones, want 4 into 3", n, set.Len())
+	}
+	for phone, want := range map[string]string{
+		"8135550001": "dnc:system",
+		"8135550002": "dnc:campaign_SALES",
+		"8135550003": "existing_lead:list_1002",
+	} {
+		if got, _ := set.Lookup(phone); got != want {
+			t.Errorf("Lookup(%s) = %q, want %q", phone, got, want)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAddFilePhones(t *testing.T) {
+	dir := t.TempDir()
+	txt := filepath.Join(dir, "dnc.txt")
+	os.WriteFile(txt, []byte("# exported 2025-01-02\n(305) 555-1234\n\n13055559876\n"), 0o644)
+	csv := filepath.Join(dir, "march.csv")
+	os.WriteFile(csv, []byte("first,phone\nAnn,305-555-1111\n"), 0o644)
+
+	set := transform.NewPhoneSet()
+	if n, err := AddFilePhones(txt, types.ReasonDNC, set); err != nil || n != 2 {
+		t.Fatalf("text file: read %d, err %v", n, err)
+	}
+	if n, err := AddFilePhones(csv, types.ReasonExistingLead, set); err != nil || n != 1 {
+		t.Fatalf("csv file: read %d, err %v", n, err)
+	}
+	for phone, want := range map[string]string{
+		"3055559876": "dnc:dnc.txt",
+		"3055551111": "existing_lead:march.csv",
+	} {
+		if got, _ := set.Lookup(phone); got != want {
+			t.Errorf("Lookup(%s) = %q, want %q", phone, got, want)
+		}
+	}
+}
@@ -5,58 +5,66 @@ import (
 	"strings"
 
 	"etl_go/extract"
+	"etl_go/transform"
+	"etl_go/types"
 )
 
 // FinalValidationResult holds cleaned dataset and dropped rows.
 type FinalValidationResult struct {
 	Cleaned   *extract.DataSet
-	Dropped   [][]string
+	Dropped   []types.Reject
 	DropCount int
 }
 
 // FinalValidate removes rows missing a phone number or both first and last names.
 // Returns a FinalValidationResult so dropped rows can be logged later.
-func FinalValidate(ds *extract.DataSet) *FinalValidationResult {
+func FinalValidate(ds *extract.DataSet) (*FinalValidationResult, error) {
 	if ds == nil {
 		fmt.Println("No dataset loaded.")
-		return &FinalValidationResult{Cleaned: ds}
+		return &FinalValidationResult{Cleaned: ds}, nil
 	}
 
-	var (
-		validRows [][]string
-		dropped   [][]string
-	)
-
-	for _, row := range ds.Rows {
-		// Defensive check for malformed rows
-		if len(row) < 9 {
-			dropped = append(dropped, row)
-			continue
-		}
-
-		first := strings.TrimSpace(row[1])
-		last := strings.TrimSpace(row[3])
-		phone := strings.TrimSpace(row[8])
-
-		// If missing phone OR both names missing → drop
-		if phone == "" || (first == "" && last == "") {
-			dropped = append(dropped, row)
-		} else {
-			validRows = append(validRows, row)
-		}
+	fn, err := FinalValidator(ds.Schema())
+	if err != nil {
+		return nil, err
 	}
 
+	cleaned, dropped := transform.ApplyParallel(ds, fn)
 	dropCount := len(dropped)
 
-	cleaned := &extract.DataSet{
-		Headers: ds.Headers,
-		Rows:    validRows,
-		Source:  ds.Source,
-	}
-
 	return &FinalValidationResult{
 		Cleaned:   cleaned,
 		Dropped:   dropped,
 		DropCount: dropCount,
+	}, nil
+}
+
+// FinalValidator returns the per-row form of FinalValidate.
+func FinalValidator(schema *extract.Schema) (transform.RowFunc, error) {
+	cols, err := schema.Require(extract.RoleFirstName, extract.RoleLastName, extract.RolePhone)
+	if err != nil {
+		return nil, fmt.Errorf("final-validate: %w", err)
 	}
+	firstIdx, lastIdx, phoneIdx := cols[0], cols[1], cols[2]
+	minLen := max(firstIdx, lastIdx, phoneIdx) + 1
+
+	return func(line int, row []string) ([]string, string) {
+		// Defensive check for malformed rows
+		if len(row) < minLen {
+			return row, types.ReasonMalformedRow
+		}
+
+		first := strings.TrimSpace(row[firstIdx])
+		last := strings.TrimSpace(row[lastIdx])
+		phone := strings.TrimSpace(row[phoneIdx])
+
+		// If missing phone OR both names missing → drop
+		if phone == "" {
+			return row, types.ReasonMissingPhone
+		}
+		if first == "" && last == "" {
+			return row, types.ReasonMissingName
+		}
+		return row, ""
+	}, nil
 }
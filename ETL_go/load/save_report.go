@@ -0,0 +1,135 @@
+package load
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"etl_go/transform"
+)
+
+// SaveReport writes the report to path. A .json extension produces JSON and
+// .html a standalone page; anything else gets the same text WriteReport
+// shows in the TUI.
+func SaveReport(report ReportSummary, path string) error {
+	var data []byte
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %v", err)
+		}
+		data = append(b, '\n')
+	case ".html", ".htm":
+		var buf bytes.Buffer
+		if err := reportPage.Execute(&buf, report); err != nil {
+			return fmt.Errorf("failed to render report: %v", err)
+		}
+		data = buf.Bytes()
+	default:
+		data = []byte(strings.Join(WriteReport(report), "\n") + "\n")
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write report: %v", err)
+	}
+	return nil
+}
+
+// SaveProfile writes a column profile to path as JSON.
+func SaveProfile(p *transform.Profile, path string) error {
+	b, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode profile: %v", err)
+	}
+	if err := os.WriteFile(path, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write profile: %v", err)
+	}
+	return nil
+}
+
+// reportPage renders a ReportSummary as a single HTML file with inline styles,
+// so it can be emailed or opened without anything else alongside it.
+var reportPage = template.Must(template.New("report").Funcs(template.FuncMap{
+	"sub": func(a, b int) int { return a - b },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>ETL Summary Report{{with .Source}} - {{.}}{{end}}</title>
+<style>
+  body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2rem auto; max-width: 48rem; color: #222; }
+  h1 { font-size: 1.5rem; border-bottom: 2px solid #7d56f4; padding-bottom: .4rem; }
+  h2 { font-size: 1.1rem; margin-top: 2rem; color: #7d56f4; }
+  table { border-collapse: collapse; width: 100%; }
+  th, td { text-align: left; padding: .35rem .6rem; border-bottom: 1px solid #e4e4e4; }
+  td.n { text-align: right; font-variant-numeric: tabular-nums; }
+  .totals td { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>ETL Summary Report</h1>
+{{with .Source}}<p>Source: <code>{{.}}</code></p>{{end}}
+{{with .AreaCodeVersion}}<p>Area code data version: {{.}}</p>{{end}}
+{{with .ZipVersion}}<p>ZIP data version: {{.}}</p>{{end}}
+
+<table class="totals">
+  <tr><td>Total rows processed</td><td class="n">{{.TotalProcessed}}</td></tr>
+  <tr><td>Total rows removed</td><td class="n">{{.TotalRemoved}}</td></tr>
+  {{- if .QuarantinedLines}}
+  <tr><td>Lines quarantined (could not be parsed)</td><td class="n">{{.QuarantinedLines}}</td></tr>
+  {{- end}}
+  <tr><td>Rows in final file</td><td class="n">{{.FinalRowCount}}</td></tr>
+</table>
+
+<h2>Removed rows</h2>
+<table>
+  <tr><td>Missing phone number</td><td class="n">{{.RemovedNoPhone}}</td></tr>
+  <tr><td>Missing first and last name</td><td class="n">{{.RemovedNoName}}</td></tr>
+  <tr><td>Invalid state</td><td class="n">{{.RemovedInvalidState}}</td></tr>
+  <tr><td>Duplicate phone number</td><td class="n">{{.RemovedDuplicates}}</td></tr>
+  <tr><td>Malformed row</td><td class="n">{{.RemovedMalformed}}</td></tr>
+  <tr><td>Invalid phone number</td><td class="n">{{.RemovedInvalidPhone}}</td></tr>
+{{- range $problem, $n := .InvalidPhones}}
+  <tr><td>&nbsp;&nbsp;&nbsp;&nbsp;{{$problem}}</td><td class="n">{{$n}}</td></tr>
+{{- end}}
+  <tr><td>Already loaded</td><td class="n">{{.RemovedExisting}}</td></tr>
+  <tr><td>Do not call</td><td class="n">{{.RemovedDNC}}</td></tr>
+  <tr><td>Same person (fuzzy match)</td><td class="n">{{.RemovedFuzzy}}</td></tr>
+</table>
+
+<h2>Name cleaning</h2>
+<table>
+  <tr><td>Cleared (contained numbers)</td><td class="n">{{.NameStats.CleanedNumeric}}</td></tr>
+  <tr><td>Special characters removed</td><td class="n">{{.NameStats.CleanedSpecial}}</td></tr>
+</table>
+
+<h2>Geographic data cleaning</h2>
+<table>
+  <tr><td>ZIP codes cleaned (contained letters)</td><td class="n">{{.GeoStats.CleanedZipLetters}}</td></tr>
+  <tr><td>ZIP codes cleaned (too short)</td><td class="n">{{.GeoStats.CleanedZipTooShort}}</td></tr>
+  <tr><td>Missing ZIP codes populated</td><td class="n">{{.GeoStats.PopulatedZip}}</td></tr>
+  <tr><td>Missing states populated</td><td class="n">{{.GeoStats.PopulatedState}}</td></tr>
+  <tr><td>ZIP-state mismatches corrected</td><td class="n">{{.GeoStats.CorrectedMismatches}}</td></tr>
+  <tr><td>State/ZIP fixed from area code</td><td class="n">{{.GeoStats.FixedFromAreaCode}}</td></tr>
+  <tr><td>Timezones filled in</td><td class="n">{{.GeoStats.PopulatedTimezone}}</td></tr>
+  <tr><td>Missing cities filled from ZIP</td><td class="n">{{.GeoStats.PopulatedCity}}</td></tr>
+  <tr><td>Cities that don't match their ZIP</td><td class="n">{{.GeoStats.CityMismatches}}</td></tr>
+  <tr><td>States guessed from ZIP ranges</td><td class="n">{{.GeoStats.StateFromZipRange}}</td></tr>
+</table>
+{{if .Steps}}
+<h2>Steps</h2>
+<table>
+  <tr><th>Step</th><th>Rows in</th><th>Rows out</th><th>Removed</th></tr>
+{{- range .Steps}}
+  <tr><td>{{.Name}}</td><td class="n">{{.RowsIn}}</td><td class="n">{{.RowsOut}}</td><td class="n">{{sub .RowsIn .RowsOut}}</td></tr>
+{{- end}}
+</table>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,46 @@
+package load
+
+import (
+	"fmt"
+
+	"leadkit/csvio"
+)
+
+// RowSink receives rows one at a time as a streaming pipeline produces them.
+type RowSink interface {
+	Write(row []string) error
+	Close() error
+}
+
+// CSVSink writes rows straight to a CSV file as they arrive.
+type CSVSink struct {
+	w     *csvio.Writer
+	Path  string
+	Count int // data rows written so far
+}
+
+// CreateCSV creates outFile and writes the header row to it.
+func CreateCSV(outFile string, headers []string) (*CSVSink, error) {
+	w, err := csvio.Create(outFile, headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %v", err)
+	}
+	return &CSVSink{w: w, Path: outFile}, nil
+}
+
+// Write appends one data row.
+func (s *CSVSink) Write(row []string) error {
+	if err := s.w.Write(row); err != nil {
+		return fmt.Errorf("failed to write row %d: %v", s.Count+1, err)
+	}
+	s.Count++
+	return nil
+}
+
+// Close flushes buffered rows and closes the file.
+func (s *CSVSink) Close() error {
+	if err := s.w.Close(); err != nil {
+		return fmt.Errorf("failed to flush rows: %v", err)
+	}
+	return nil
+}
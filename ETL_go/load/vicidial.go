@@ -0,0 +1,264 @@
+package load
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"etl_go/extract"
+
+	_ "github.com/go-sql-driver/mysql" // registers the "mysql" driver
+)
+
+// VicidialTable is the table VICIdial dials leads from.
+const VicidialTable = "vicidial_list"
+
+// DefaultVicidialBatch is how many rows go into one INSERT when
+// VicidialOptions.BatchSize is zero.
+const DefaultVicidialBatch = 500
+
+// maxPlaceholders is the most ? parameters MySQL accepts in one statement.
+const maxPlaceholders = 65535
+
+// VicidialColumn maps a schema role to a vicidial_list column. Values longer
+// than Width characters are cut to fit.
+type VicidialColumn struct {
+	Role   extract.Role
+	Column string
+	Width  int
+}
+
+// VicidialColumns is how the 13-column schema lands in vicidial_list. Roles
+// missing from a dataset are left at the table's defaults.
+var VicidialColumns = []VicidialColumn{
+	{extract.RoleSourceID, "vendor_lead_code", 20},
+	{extract.RoleFirstName, "first_name", 30},
+	{extract.RoleMiddleName, "middle_initial", 1},
+	{extract.RoleLastName, "last_name", 30},
+	{extract.RoleAddress1, "address1", 100},
+	{extract.RoleCity, "city", 50},
+	{extract.RoleState, "state", 2},
+	{extract.RoleZip, "postal_code", 10},
+	{extract.RolePhone, "phone_number", 18},
+	{extract.RoleAddress3, "address3", 100},
+	{extract.RoleProvince, "province", 50},
+	{extract.RoleEmail, "email", 70},
+	{extract.RoleTrustedURL, "comments", 255},
+}
+
+// VicidialOptions controls LoadVicidial.
+type VicidialOptions struct {
+	ListID    int64
+	BatchSize int    // rows per INSERT and transaction; DefaultVicidialBatch if zero, capped by maxPlaceholders
+	DryRun    bool   // build the rows and batches but don't touch the database
+	PhoneCode string // country calling code; "1" if empty
+	Status    string // initial lead status; "NEW" if empty
+}
+
+// VicidialResult summarizes a load.
+type VicidialResult struct {
+	Rows      int      // rows prepared for insert
+	Inserted  int      // rows committed; zero on a dry run
+	Batches   int      // INSERT statements run, or that would run
+	Skipped   int      // rows without a phone number
+	Truncated int      // values cut to fit their column
+	Columns   []string // vicidial_list columns written, in INSERT order
+}
+
+// Lines describes the result for the output window.
+func (r VicidialResult) Lines(opts VicidialOptions) []string {
+	var lines []string
+	if opts.DryRun {
+		lines = append(lines, fmt.Sprintf("Dry run: would insert %d rows into %s for list %d in %d batches", r.Rows, VicidialTable, opts.ListID, r.Batches))
+	} else {
+		lines = append(lines, fmt.Sprintf("Inserted %d rows into %s for list %d in %d batches", r.Inserted, VicidialTable, opts.ListID, r.Batches))
+	}
+	lines = append(lines, fmt.Sprintf("  columns: %s", strings.Join(r.Columns, ", ")))
+	if r.Skipped > 0 {
+		lines = append(lines, fmt.Sprintf("  %d rows skipped (no phone number)", r.Skipped))
+	}
+	if r.Truncated > 0 {
+		lines = append(lines, fmt.Sprintf("  %d values truncated to fit their column", r.Truncated))
+	}
+	return lines
+}
+
+// vicidialBatch is the INSERT for a run of rows.
+type vicidialBatch struct {
+	query string
+	args  []any
+	rows  int
+}
+
+// LoadVicidial inserts the dataset into vicidial_list for opts.ListID. Each
+// batch is one multi-row INSERT in its own transaction, so a failure leaves
+// earlier batches committed and reports how many rows made it in. The list
+// must already exist in vicidial_lists. db may be nil on a dry run.
+func LoadVicidial(ctx context.Context, db *sql.DB, ds *extract.DataSet, opts VicidialOptions) (VicidialResult, error) {
+	if ds == nil || len(ds.Rows) == 0 {
+		return VicidialResult{}, fmt.Errorf("no data to load")
+	}
+	if opts.ListID <= 0 {
+		return VicidialResult{}, fmt.Errorf("a list_id is required")
+	}
+
+	batches, result, err := vicidialBatches(ds, opts)
+	if err != nil {
+		return result, err
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+	if db == nil {
+		return result, fmt.Errorf("no database connection")
+	}
+
+	var exists int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM vicidial_lists WHERE list_id = ?", opts.ListID).Scan(&exists); err != nil {
+		return result, fmt.Errorf("checking list %d: %w", opts.ListID, err)
+	}
+	if exists == 0 {
+		return result, fmt.Errorf("list %d does not exist in vicidial_lists", opts.ListID)
+	}
+
+	for i, b := range batches {
+		if err := insertBatch(ctx, db, b); err != nil {
+			return result, fmt.Errorf("batch %d of %d (%d rows already inserted): %w", i+1, len(batches), result.Inserted, err)
+		}
+		result.Inserted += b.rows
+	}
+	return result, nil
+}
+
+func insertBatch(ctx context.Context, db *sql.DB, b vicidialBatch) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, b.query, b.args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// vicidialBatches maps the dataset's rows to vicidial_list values and groups
+// them into INSERT statements.
+func vicidialBatches(ds *extract.DataSet, opts VicidialOptions) ([]vicidialBatch, VicidialResult, error) {
+	schema := ds.Schema()
+	phoneIdx := schema.Lookup(extract.RolePhone)
+	if phoneIdx < 0 {
+		return nil, VicidialResult{}, fmt.Errorf("load-vicidial: no column has the %s role", extract.RolePhone)
+	}
+
+	type mapped struct {
+		VicidialColumn
+		idx int
+	}
+	var cols []mapped
+	for _, c := range VicidialColumns {
+		if i := schema.Lookup(c.Role); i >= 0 {
+			cols = append(cols, mapped{c, i})
+		}
+	}
+	tzIdx := schema.Lookup(extract.RoleTimezone)
+
+	phoneCode := cmp.Or(opts.PhoneCode, "1")
+	status := cmp.Or(opts.Status, "NEW")
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultVicidialBatch
+	}
+
+	// Each row takes the four fixed values that aren't constants plus one
+	// per mapped role
+	if perRow := 4 + len(cols); batchSize*perRow > maxPlaceholders {
+		return nil, VicidialResult{}, fmt.Errorf("load-vicidial: batch=%d needs %d placeholders per INSERT but MySQL allows %d; with %d columns mapped use batch=%d or less",
+			batchSize, batchSize*perRow, maxPlaceholders, len(cols), maxPlaceholders/perRow)
+	}
+
+	// Fixed columns first, then one per mapped role
+	result := VicidialResult{Columns: []string{"list_id", "status", "entry_date", "called_since_last_reset", "phone_code", "gmt_offset_now"}}
+	for _, c := range cols {
+		result.Columns = append(result.Columns, c.Column)
+	}
+	placeholders := "(?, ?, NOW(), 'N', ?, ?" + strings.Repeat(", ?", len(cols)) + ")"
+	prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES ", VicidialTable, strings.Join(result.Columns, ", "))
+
+	var batches []vicidialBatch
+	var cur vicidialBatch
+	flush := func() {
+		if cur.rows == 0 {
+			return
+		}
+		cur.query = prefix + strings.TrimSuffix(strings.Repeat(placeholders+", ", cur.rows), ", ")
+		batches = append(batches, cur)
+		cur = vicidialBatch{}
+	}
+
+	now := time.Now()
+	for _, row := range ds.Rows {
+		phone := vicidialPhone(row[phoneIdx], phoneCode)
+		if phone == "" {
+			result.Skipped++
+			continue
+		}
+
+		gmt := "0.00"
+		if tzIdx >= 0 {
+			gmt = gmtOffset(row[tzIdx], now)
+		}
+		cur.args = append(cur.args, opts.ListID, status, phoneCode, gmt)
+		for _, c := range cols {
+			v := row[c.idx]
+			if c.Role == extract.RolePhone {
+				v = phone
+			}
+			if r := []rune(v); len(r) > c.Width {
+				v = string(r[:c.Width])
+				result.Truncated++
+			}
+			cur.args = append(cur.args, v)
+		}
+		cur.rows++
+		result.Rows++
+		if cur.rows == batchSize {
+			flush()
+		}
+	}
+	flush()
+
+	result.Batches = len(batches)
+	return batches, result, nil
+}
+
+// vicidialPhone keeps only the digits of a phone number and drops a leading
+// country code when it matches code.
+func vicidialPhone(raw, code string) string {
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, raw)
+	if len(digits) == 10+len(code) && strings.HasPrefix(digits, code) {
+		digits = digits[len(code):]
+	}
+	return digits
+}
+
+// gmtOffset returns a timezone's current UTC offset in hours, formatted for
+// vicidial_list.gmt_offset_now, or "0.00" when the zone is unknown.
+func gmtOffset(tz string, now time.Time) string {
+	tz = strings.TrimSpace(tz)
+	loc, err := time.LoadLocation(tz)
+	if tz == "" || err != nil {
+		return "0.00"
+	}
+	_, secs := now.In(loc).Zone()
+	return fmt.Sprintf("%.2f", float64(secs)/3600)
+}
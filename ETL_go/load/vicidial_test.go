@@ -0,0 +1,82 @@
+package load
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"etl_go/extract"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func vicidialTestData() *extract.DataSet {
+	return &extract.DataSet{
+		Headers: []string{"first_name", "middle_name", "last_name", "state", "zip", "phone"},
+		Rows: [][]string{
+			{"Ann", "Marie", "Lee", "FL", "33101", "(305) 555-1234"},
+			{"Bob", "", "Ray", "GA", "30301", ""},
+			{"Cy", "", "Ng", "TX", "75201", "1-214-555-9876"},
+			{"Dee", "", "Fox", "NY", "10001", "2125550000"},
+		},
+	}
+}
+
+func TestLoadVicidialDryRun(t *testing.T) {
+	opts := VicidialOptions{ListID: 1001, BatchSize: 2, DryRun: true}
+	got, err := LoadVicidial(context.Background(), nil, vicidialTestData(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Rows != 3 || got.Batches != 2 || got.Skipped != 1 || got.Truncated != 1 || got.Inserted != 0 {
+		t.Errorf("dry run result = %+v", got)
+	}
+}
+
+func TestLoadVicidialRejectsOversizedBatch(t *testing.T) {
+	// Six mapped columns plus four fixed values make 10 placeholders a row
+	for batch, ok := range map[int]bool{6553: true, 6554: false} {
+		opts := VicidialOptions{ListID: 1001, BatchSize: batch, DryRun: true}
+		_, err := LoadVicidial(context.Background(), nil, vicidialTestData(), opts)
+		if (err == nil) != ok {
+			t.Errorf("batch=%d: err = %v", batch, err)
+		}
+	}
+}
+
+func TestLoadVicidialBatches(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	insert := regexp.QuoteMeta("INSERT INTO vicidial_list (list_id, status, entry_date, called_since_last_reset, phone_code, gmt_offset_now, first_name, middle_initial, last_name, state, postal_code, phone_number) VALUES ")
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM vicidial_lists WHERE list_id = ?")).
+		WithArgs(1001).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectExec(insert).
+		WithArgs(
+			1001, "NEW", "1", "0.00", "Ann", "M", "Lee", "FL", "33101", "3055551234",
+			1001, "NEW", "1", "0.00", "Cy", "", "Ng", "TX", "75201", "2145559876",
+		).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(insert).WillReturnError(errors.New("duplicate entry"))
+	mock.ExpectRollback()
+
+	opts := VicidialOptions{ListID: 1001, BatchSize: 2}
+	got, err := LoadVicidial(context.Background(), db, vicidialTestData(), opts)
+	if err == nil {
+		t.Fatal("expected the second batch to fail")
+	}
+	if got.Inserted != 2 {
+		t.Errorf("Inserted = %d, want 2 (first batch committed)", got.Inserted)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
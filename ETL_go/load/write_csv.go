@@ -1,9 +1,7 @@
 package load
 
 import (
-	"encoding/csv"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -19,33 +17,36 @@ func WriteCSV(ds *extract.DataSet, outFile string) error {
 
 	// If no file name provided, build one based on source
 	if outFile == "" {
-		base := "output"
-		if ds.Source != "" {
-			base = strings.TrimSuffix(filepath.Base(ds.Source), filepath.Ext(ds.Source))
-		}
-		outFile = fmt.Sprintf("%s_cleaned.csv", base)
+		outFile = CleanedFileName(ds.Source)
 	}
 
-	// Create output file
-	f, err := os.Create(outFile)
+	sink, err := CreateCSV(outFile, ds.Headers)
 	if err != nil {
-		return fmt.Errorf("failed to create output file: %v", err)
-	}
-	defer f.Close()
-
-	w := csv.NewWriter(f)
-	defer w.Flush()
-
-	// Write header row first
-	if err := w.Write(ds.Headers); err != nil {
-		return fmt.Errorf("failed to write headers: %v", err)
+		return err
 	}
 
 	// Write all rows
-	if err := w.WriteAll(ds.Rows); err != nil {
-		return fmt.Errorf("failed to write rows: %v", err)
+	for _, row := range ds.Rows {
+		if err := sink.Write(row); err != nil {
+			sink.Close()
+			return err
+		}
 	}
+	return sink.Close()
+}
+
+// CleanedFileName builds the default output name for a source file,
+// e.g. "leads.xlsx (sheet: Sheet1)" becomes "leads_cleaned.csv".
+func CleanedFileName(source string) string {
+	return OutputFileName(source, "cleaned", ".csv")
+}
 
-	fmt.Printf("✅ %d rows written to %s\n", len(ds.Rows), outFile)
-	return nil
+// OutputFileName builds "<source base>_<suffix><ext>" so every file written
+// for a source sits next to its _cleaned.csv.
+func OutputFileName(source, suffix, ext string) string {
+	base := "output"
+	if source != "" {
+		base = strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+	}
+	return fmt.Sprintf("%s_%s%s", base, suffix, ext)
 }
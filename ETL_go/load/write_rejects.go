@@ -0,0 +1,116 @@
+package load
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"etl_go/types"
+
+	"leadkit/csvio"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// WriteRejects exports rejected rows with their source line and reason code.
+// A .xlsx path produces a workbook; anything else is written as CSV. With no
+// rejects the file has just the header, so a rejects file left by an earlier
+// run is never mistaken for this one's.
+func WriteRejects(rejects []types.Reject, path string) error {
+	table := rejectTable(rejects)
+	if strings.EqualFold(filepath.Ext(path), ".xlsx") {
+		return writeRejectsXLSX(table, path)
+	}
+	return writeRejectsCSV(table, path)
+}
+
+// rejectTable lays rejects out as rows under a header of source_line, reason
+// and every data column seen across the rejects in first-seen order. Rows
+// dropped before and after a "drop" command have different headers, so each
+// value is placed by column name rather than position. A name that appears
+// more than once in a header is told apart by its occurrence, so the second
+// "Phone" never lands on the first. Cells past the end of a row's header,
+// as in malformed rows kept by --ragged reject, go in extra_1, extra_2, ...
+// columns after all the named ones.
+func rejectTable(rejects []types.Reject) [][]string {
+	type column struct {
+		name string
+		n    int // occurrence of name in the reject's header, from 0
+	}
+	keys := func(headers []string) []column {
+		seen := make(map[string]int)
+		cols := make([]column, len(headers))
+		for i, h := range headers {
+			cols[i] = column{h, seen[h]}
+			seen[h]++
+		}
+		return cols
+	}
+
+	header := []string{"source_line", "reason"}
+	colIndex := make(map[column]int)
+	extras := 0
+	for _, r := range rejects {
+		for _, c := range keys(r.Headers) {
+			if _, ok := colIndex[c]; !ok {
+				colIndex[c] = len(header)
+				header = append(header, c.name)
+			}
+		}
+		extras = max(extras, len(r.Row)-len(r.Headers))
+	}
+	firstExtra := len(header)
+	for i := 1; i <= extras; i++ {
+		header = append(header, "extra_"+strconv.Itoa(i))
+	}
+
+	table := [][]string{header}
+	for _, r := range rejects {
+		row := make([]string, len(header))
+		row[0] = strconv.Itoa(r.Line)
+		row[1] = r.Reason
+		cols := keys(r.Headers)
+		for i, val := range r.Row {
+			if i < len(cols) {
+				row[colIndex[cols[i]]] = val
+			} else {
+				row[firstExtra+i-len(cols)] = val
+			}
+		}
+		table = append(table, row)
+	}
+	return table
+}
+
+func writeRejectsCSV(table [][]string, path string) error {
+	if err := csvio.WriteAll(path, nil, table); err != nil {
+		return fmt.Errorf("failed to write rejects: %v", err)
+	}
+	return nil
+}
+
+func writeRejectsXLSX(table [][]string, path string) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	const sheet = "Rejected"
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return fmt.Errorf("failed to create rejects sheet: %v", err)
+	}
+	for i, row := range table {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		values := make([]interface{}, len(row))
+		for j, v := range row {
+			values[j] = v
+		}
+		if err := f.SetSheetRow(sheet, cell, &values); err != nil {
+			return fmt.Errorf("failed to write rejects row %d: %v", i+1, err)
+		}
+	}
+
+	if err := f.SaveAs(path); err != nil {
+		return fmt.Errorf("failed to save rejects workbook: %v", err)
+	}
+	return nil
+}
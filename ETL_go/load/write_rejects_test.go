@@ -0,0 +1,80 @@
+package load
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"etl_go/types"
+
+	"leadkit/csvio"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestWriteRejectsKeepsDuplicateHeaders(t *testing.T) {
+	// Two Phone columns, and a drop of Note between the two rejects
+	before := []string{"Name", "Phone", "Note", "Phone"}
+	after := []string{"Name", "Phone", "Phone"}
+	rejects := []types.Reject{
+		{Line: 2, Reason: "invalid_phone:too_short", Row: []string{"Ann", "555", "call back", "8135551111"}, Headers: before},
+		{Line: 7, Reason: "duplicate_of:2", Row: []string{"Bob", "8135552222", "4045553333"}, Headers: after},
+	}
+	want := [][]string{
+		{"source_line", "reason", "Name", "Phone", "Note", "Phone"},
+		{"2", "invalid_phone:too_short", "Ann", "555", "call back", "8135551111"},
+		{"7", "duplicate_of:2", "Bob", "8135552222", "", "4045553333"},
+	}
+
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "rejects.csv")
+	if err := WriteRejects(rejects, csvPath); err != nil {
+		t.Fatalf("WriteRejects csv: %v", err)
+	}
+	got, err := csvio.ReadAll(csvPath, csvio.ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("csv rejects:\ngot  %v\nwant %v", got, want)
+	}
+
+	xlsxPath := filepath.Join(dir, "rejects.xlsx")
+	if err := WriteRejects(rejects, xlsxPath); err != nil {
+		t.Fatalf("WriteRejects xlsx: %v", err)
+	}
+	wb, err := excelize.OpenFile(xlsxPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	got, err = wb.GetRows("Rejected")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Excel drops trailing empty cells, so pad the rows before comparing
+	for i := range got {
+		got[i] = append(got[i], make([]string, len(want[0])-len(got[i]))...)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("xlsx rejects:\ngot  %v\nwant %v", got, want)
+	}
+}
+
+func TestRejectTableKeepsOverflowCells(t *testing.T) {
+	headers := []string{"Name", "Phone"}
+	rejects := []types.Reject{
+		{Line: 3, Reason: "malformed_row:4_columns", Row: []string{"Ann", "8135551111", "x", "y"}, Headers: headers},
+		{Line: 4, Reason: "malformed_row:3_columns", Row: []string{"Bob", "8135552222", "z"}, Headers: headers},
+		{Line: 5, Reason: "invalid_phone:length", Row: []string{"Cy", "555"}, Headers: headers},
+	}
+	want := [][]string{
+		{"source_line", "reason", "Name", "Phone", "extra_1", "extra_2"},
+		{"3", "malformed_row:4_columns", "Ann", "8135551111", "x", "y"},
+		{"4", "malformed_row:3_columns", "Bob", "8135552222", "z", ""},
+		{"5", "invalid_phone:length", "Cy", "555", "", ""},
+	}
+	if got := rejectTable(rejects); !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %v\nwant %v", got, want)
+	}
+}
@@ -2,20 +2,74 @@ package load
 
 import (
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 
 	"etl_go/types"
 )
 
 // ReportSummary holds all stats for the ETL run summary.
 type ReportSummary struct {
-	TotalProcessed      int
-	TotalRemoved        int
-	RemovedNoPhone      int
-	RemovedNoName       int
-	RemovedInvalidState int
-	RemovedDuplicates   int
-	GeoStats			types.GeoStats
-	FinalRowCount       int
+	Source              string          `json:"source"`
+	TotalProcessed      int             `json:"total_processed"` // rows in the file as loaded
+	TotalRemoved        int             `json:"total_removed"`
+	QuarantinedLines    int             `json:"quarantined_lines"` // unparseable CSV lines set aside on load
+	RemovedNoPhone      int             `json:"removed_no_phone"`
+	RemovedNoName       int             `json:"removed_no_name"`
+	RemovedInvalidState int             `json:"removed_invalid_state"`
+	RemovedDuplicates   int             `json:"removed_duplicates"`
+	RemovedMalformed    int             `json:"removed_malformed"`
+	RemovedInvalidPhone int             `json:"removed_invalid_phone"`
+	RemovedExisting     int             `json:"removed_existing"` // already loaded in an earlier list
+	RemovedDNC          int             `json:"removed_dnc"`
+	RemovedFuzzy        int             `json:"removed_fuzzy"`            // merged into another row by dedup-fuzzy
+	InvalidPhones       map[string]int  `json:"invalid_phones,omitempty"` // by problem, e.g. "toll_free"
+	NameStats           types.NameStats `json:"name_stats"`
+	GeoStats            types.GeoStats  `json:"geo_stats"`
+	Steps               []StepStats     `json:"steps"`
+	AreaCodeVersion     string          `json:"area_code_version"`
+	ZipVersion          string          `json:"zip_version"`
+	FinalRowCount       int             `json:"final_row_count"`
+}
+
+// StepStats records the row counts around one pipeline step.
+type StepStats struct {
+	Name    string `json:"name"`
+	RowsIn  int    `json:"rows_in"`
+	RowsOut int    `json:"rows_out"`
+}
+
+// AddRejects adds rejected rows to the removal totals, counted by reason code.
+func (r *ReportSummary) AddRejects(rejects []types.Reject) {
+	for _, rej := range rejects {
+		r.TotalRemoved++
+		switch rej.Code() {
+		case types.ReasonMissingPhone:
+			r.RemovedNoPhone++
+		case types.ReasonMissingName:
+			r.RemovedNoName++
+		case types.ReasonInvalidState:
+			r.RemovedInvalidState++
+		case types.ReasonDuplicateOf:
+			r.RemovedDuplicates++
+		case types.ReasonMalformedRow:
+			r.RemovedMalformed++
+		case types.ReasonInvalidPhone:
+			r.RemovedInvalidPhone++
+			if r.InvalidPhones == nil {
+				r.InvalidPhones = make(map[string]int)
+			}
+			_, problem, _ := strings.Cut(rej.Reason, ":")
+			r.InvalidPhones[problem]++
+		case types.ReasonExistingLead:
+			r.RemovedExisting++
+		case types.ReasonDNC:
+			r.RemovedDNC++
+		case types.ReasonFuzzyDuplicateOf:
+			r.RemovedFuzzy++
+		}
+	}
 }
 
 // WriteReport returns the summary of ETL operations as formatted strings for the output window.
@@ -25,12 +79,33 @@ func WriteReport(report ReportSummary) []string {
 		"",
 		fmt.Sprintf("Total rows processed: %d", report.TotalProcessed),
 		fmt.Sprintf("Total rows removed:   %d", report.TotalRemoved),
+	}
+	if report.QuarantinedLines > 0 {
+		lines = append(lines, fmt.Sprintf("Lines quarantined:    %d (could not be parsed)", report.QuarantinedLines))
+	}
+	lines = append(lines,
 		"",
 		"  Breakdown:",
 		fmt.Sprintf("    - %d removed for missing phone number", report.RemovedNoPhone),
 		fmt.Sprintf("    - %d removed for missing first AND last name", report.RemovedNoName),
 		fmt.Sprintf("    - %d removed for invalid state", report.RemovedInvalidState),
 		fmt.Sprintf("    - %d removed for duplicate phone numbers", report.RemovedDuplicates),
+		fmt.Sprintf("    - %d removed as malformed rows", report.RemovedMalformed),
+		fmt.Sprintf("    - %d removed for invalid phone numbers", report.RemovedInvalidPhone),
+	)
+	for _, problem := range slices.Sorted(maps.Keys(report.InvalidPhones)) {
+		lines = append(lines, fmt.Sprintf("        %d %s", report.InvalidPhones[problem], problem))
+	}
+	lines = append(lines,
+		fmt.Sprintf("    - %d removed as already loaded", report.RemovedExisting),
+		fmt.Sprintf("    - %d removed as do-not-call", report.RemovedDNC),
+		fmt.Sprintf("    - %d merged as the same person (fuzzy match)", report.RemovedFuzzy),
+	)
+	lines = append(lines,
+		"",
+		"  Name Cleaning:",
+		fmt.Sprintf("    - %d names cleared (contained numbers)", report.NameStats.CleanedNumeric),
+		fmt.Sprintf("    - %d names cleaned (special characters)", report.NameStats.CleanedSpecial),
 		"",
 		"  Geographic Data Cleaning:",
 		fmt.Sprintf("    - %d ZIP codes cleaned (contained letters)", report.GeoStats.CleanedZipLetters),
@@ -39,11 +114,32 @@ func WriteReport(report ReportSummary) []string {
 		fmt.Sprintf("    - %d missing states populated", report.GeoStats.PopulatedState),
 		fmt.Sprintf("    - %d ZIP-State mismatches corrected", report.GeoStats.CorrectedMismatches),
 		fmt.Sprintf("    - %d state/ZIP pairs fixed from area codes", report.GeoStats.FixedFromAreaCode),
+		fmt.Sprintf("    - %d timezones filled in", report.GeoStats.PopulatedTimezone),
+		fmt.Sprintf("    - %d missing cities filled from ZIP", report.GeoStats.PopulatedCity),
+		fmt.Sprintf("    - %d cities that don't match their ZIP", report.GeoStats.CityMismatches),
+		fmt.Sprintf("    - %d states guessed from ZIP ranges (ZIP not in the ZIP table)", report.GeoStats.StateFromZipRange),
 		"",
+	)
+
+	if len(report.Steps) > 0 {
+		lines = append(lines, "  Steps:")
+		for _, st := range report.Steps {
+			lines = append(lines, fmt.Sprintf("    - %-16s %6d -> %-6d (%d removed)", st.Name, st.RowsIn, st.RowsOut, st.RowsIn-st.RowsOut))
+		}
+		lines = append(lines, "")
+	}
+
+	if report.AreaCodeVersion != "" {
+		lines = append(lines, fmt.Sprintf("Area code data version: %s", report.AreaCodeVersion))
+	}
+	if report.ZipVersion != "" {
+		lines = append(lines, fmt.Sprintf("ZIP data version: %s", report.ZipVersion))
+	}
+	lines = append(lines,
 		fmt.Sprintf("Total rows in final, ready-to-load file: %d", report.FinalRowCount),
 		"",
 		"====================================================",
-	}
+	)
 
 	return lines
 }
@@ -0,0 +1,236 @@
+package load
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"etl_go/extract"
+	"etl_go/types"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Sheet names used by WriteXLSX.
+const (
+	LeadsSheet  = "Leads"
+	ReportSheet = "Report"
+)
+
+// maxColumnWidth caps auto-sized columns so a long note doesn't push the rest
+// of the sheet off screen.
+const maxColumnWidth = 60
+
+// XLSXOptions adds an optional second sheet to WriteXLSX's output.
+type XLSXOptions struct {
+	Report  *ReportSummary // summary lines at the top of the Report sheet
+	Rejects []types.Reject // rejected rows below the summary
+}
+
+// WriteXLSX writes the dataset to an Excel workbook with a bold, frozen header
+// row and auto-sized columns. ZIP and phone columns, and any value Excel would
+// mangle (leading zeros, long digit strings), are stored as text; other
+// numbers are stored as numbers.
+func WriteXLSX(ds *extract.DataSet, outFile string, opts XLSXOptions) error {
+	if ds == nil || len(ds.Rows) == 0 {
+		return fmt.Errorf("no data to write")
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	if err := f.SetSheetName("Sheet1", LeadsSheet); err != nil {
+		return fmt.Errorf("failed to create sheet: %v", err)
+	}
+	styles, err := newXLSXStyles(f)
+	if err != nil {
+		return err
+	}
+
+	schema := ds.Schema()
+	textCols := make(map[int]bool)
+	for _, role := range []extract.Role{extract.RoleZip, extract.RolePhone} {
+		if i := schema.Lookup(role); i >= 0 {
+			textCols[i] = true
+		}
+	}
+
+	if err := writeTable(f, LeadsSheet, ds.Headers, ds.Rows, textCols, styles); err != nil {
+		return err
+	}
+	if opts.Report != nil || len(opts.Rejects) > 0 {
+		if err := writeReportSheet(f, opts, styles); err != nil {
+			return err
+		}
+	}
+
+	if err := f.SaveAs(outFile); err != nil {
+		return fmt.Errorf("failed to save workbook: %v", err)
+	}
+	return nil
+}
+
+type xlsxStyles struct {
+	header, text int
+}
+
+func newXLSXStyles(f *excelize.File) (xlsxStyles, error) {
+	header, err := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"D9D2F5"}},
+	})
+	if err != nil {
+		return xlsxStyles{}, fmt.Errorf("failed to create header style: %v", err)
+	}
+	text, err := f.NewStyle(&excelize.Style{NumFmt: 49}) // "@", text
+	if err != nil {
+		return xlsxStyles{}, fmt.Errorf("failed to create text style: %v", err)
+	}
+	return xlsxStyles{header: header, text: text}, nil
+}
+
+// writeTable streams a header and rows into sheet, freezing the header row.
+func writeTable(f *excelize.File, sheet string, headers []string, rows [][]string, textCols map[int]bool, styles xlsxStyles) error {
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return fmt.Errorf("failed to open sheet %s: %v", sheet, err)
+	}
+
+	// Column widths and panes must be set before the first row
+	for i, w := range columnWidths(headers, rows) {
+		if err := sw.SetColWidth(i+1, i+1, w); err != nil {
+			return err
+		}
+	}
+	if err := sw.SetPanes(&excelize.Panes{
+		Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft",
+	}); err != nil {
+		return err
+	}
+
+	header := make([]interface{}, len(headers))
+	for i, h := range headers {
+		header[i] = excelize.Cell{StyleID: styles.header, Value: h}
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		return fmt.Errorf("failed to write header: %v", err)
+	}
+
+	for r, row := range rows {
+		values := make([]interface{}, len(row))
+		for i, v := range row {
+			values[i] = typedCell(v, textCols[i], styles.text)
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, r+2)
+		if err := sw.SetRow(cell, values); err != nil {
+			return fmt.Errorf("failed to write row %d: %v", r+1, err)
+		}
+	}
+	return sw.Flush()
+}
+
+// typedCell stores v as a number when Excel would show it unchanged, and as
+// text otherwise.
+func typedCell(v string, text bool, textStyle int) interface{} {
+	if v == "" {
+		return nil
+	}
+	if !text && isPlainNumber(v) {
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	}
+	return excelize.Cell{StyleID: textStyle, Value: v}
+}
+
+// isPlainNumber reports whether v is a number that survives a round trip
+// through Excel: no leading zero, no exponent and short enough not to be
+// shown in scientific notation.
+func isPlainNumber(v string) bool {
+	digits := 0
+	for i, r := range v {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == '-' && i == 0:
+		case r == '.':
+		default:
+			return false
+		}
+	}
+	if digits == 0 || digits > 9 {
+		return false
+	}
+	// The leading zero comes after any sign: "-0123" is as much an ID as "0123"
+	unsigned := strings.TrimPrefix(v, "-")
+	if len(unsigned) > 1 && unsigned[0] == '0' && unsigned[1] != '.' {
+		return false
+	}
+	return true
+}
+
+// columnWidths sizes each column to its longest value, in characters.
+func columnWidths(headers []string, rows [][]string) []float64 {
+	widths := make([]float64, len(headers))
+	measure := func(i int, v string) {
+		if i >= len(widths) {
+			return
+		}
+		if w := float64(utf8.RuneCountInString(v) + 2); w > widths[i] {
+			widths[i] = min(w, maxColumnWidth)
+		}
+	}
+	for i, h := range headers {
+		measure(i, h)
+	}
+	for _, row := range rows {
+		for i, v := range row {
+			measure(i, v)
+		}
+	}
+	return widths
+}
+
+// writeReportSheet adds the summary report followed by the rejected rows.
+func writeReportSheet(f *excelize.File, opts XLSXOptions, styles xlsxStyles) error {
+	if _, err := f.NewSheet(ReportSheet); err != nil {
+		return fmt.Errorf("failed to create report sheet: %v", err)
+	}
+
+	row := 1
+	setRow := func(values []interface{}) error {
+		cell, _ := excelize.CoordinatesToCellName(1, row)
+		row++
+		return f.SetSheetRow(ReportSheet, cell, &values)
+	}
+
+	if opts.Report != nil {
+		for _, line := range WriteReport(*opts.Report) {
+			if err := setRow([]interface{}{line}); err != nil {
+				return err
+			}
+		}
+		row++
+	}
+
+	if len(opts.Rejects) > 0 {
+		table := rejectTable(opts.Rejects)
+		headerRow := row
+		for _, r := range table {
+			values := make([]interface{}, len(r))
+			for i, v := range r {
+				values[i] = v
+			}
+			if err := setRow(values); err != nil {
+				return err
+			}
+		}
+		first, _ := excelize.CoordinatesToCellName(1, headerRow)
+		last, _ := excelize.CoordinatesToCellName(len(table[0]), headerRow)
+		if err := f.SetCellStyle(ReportSheet, first, last, styles.header); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package load
+
+import (
+	"path/filepath"
+	"testing"
+
+	"etl_go/extract"
+	"etl_go/types"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestWriteXLSXKeepsZipAndPhoneAsText(t *testing.T) {
+	ds := &extract.DataSet{
+		Headers: []string{"ID", "Zip", "Phone", "Note"},
+		Rows: [][]string{
+			{"42", "06001", "8135559999", "0123"},
+		},
+	}
+	path := filepath.Join(t.TempDir(), "out.xlsx")
+	report := ReportSummary{TotalProcessed: 2, FinalRowCount: 1}
+	rejects := []types.Reject{{Line: 3, Reason: "missing_phone", Row: []string{"7", "06002", "", ""}, Headers: ds.Headers}}
+	if err := WriteXLSX(ds, path, XLSXOptions{Report: &report, Rejects: rejects}); err != nil {
+		t.Fatalf("WriteXLSX: %v", err)
+	}
+
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	for cell, want := range map[string]string{"B2": "06001", "C2": "8135559999", "D2": "0123"} {
+		got, _ := f.GetCellValue(LeadsSheet, cell)
+		typ, _ := f.GetCellType(LeadsSheet, cell)
+		if got != want || (typ != excelize.CellTypeInlineString && typ != excelize.CellTypeSharedString) {
+			t.Errorf("%s = %q (type %v), want text %q", cell, got, typ, want)
+		}
+	}
+	// Numbers are written without a type attribute, which Excel reads as numeric
+	if typ, _ := f.GetCellType(LeadsSheet, "A2"); typ != excelize.CellTypeNumber && typ != excelize.CellTypeUnset {
+		t.Errorf("expected ID to be a number, got type %v", typ)
+	}
+
+	panes, err := f.GetPanes(LeadsSheet)
+	if err != nil || !panes.Freeze || panes.YSplit != 1 {
+		t.Errorf("expected a frozen header row, got %+v (%v)", panes, err)
+	}
+
+	rows, err := f.GetRows(ReportSheet)
+	if err != nil || len(rows) == 0 {
+		t.Fatalf("expected a report sheet: %v", err)
+	}
+	last := rows[len(rows)-1]
+	if len(last) < 4 || last[1] != "missing_phone" || last[3] != "06002" {
+		t.Errorf("unexpected reject row %v", last)
+	}
+}
+
+func TestIsPlainNumber(t *testing.T) {
+	tests := map[string]bool{
+		"42":         true,
+		"-42":        true,
+		"0.5":        true,
+		"-0.5":       true,
+		"0":          true,
+		"0123":       false,
+		"-0123":      false,
+		"8135559999": false, // too long to survive Excel
+		"1e5":        false,
+		"-":          false,
+	}
+	for v, want := range tests {
+		if got := isPlainNumber(v); got != want {
+			t.Errorf("isPlainNumber(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
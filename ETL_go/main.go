@@ -1,20 +1,62 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
+	"etl_go/extract"
+
+	"astguiclient"
+
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+func usage() {
+	fmt.Println("Usage: etl_go [--recipe recipe.yaml] [--sheet name | --merge-sheets all] [--delimiter tab] [--encoding windows-1252] [--ragged pad] [--conf astguiclient.conf] <inputfile.csv | inputfile.xlsx>")
+	fmt.Println("       etl_go run --input <file> [--steps drop:9,10,12,clean-all | --recipe recipe.yaml] [--out file.csv] [--report report.json]")
+	fmt.Println("       etl_go stream [--drop 9,10,12] <inputfile.csv> [outputfile.csv]")
+}
+
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Println("Usage: etl_go <inputfile.csv | inputfile.xlsx>")
+		usage()
+		os.Exit(1)
+	}
+
+	if os.Args[1] == "run" {
+		if err := runBatch(os.Args[2:], os.Stderr); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if os.Args[1] == "stream" {
+		if err := runStream(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fs := flag.NewFlagSet("etl_go", flag.ExitOnError)
+	recipeFile := fs.String("recipe", "", "recipe file to apply after loading the input")
+	sheet := fs.String("sheet", "", "worksheet to load from an .xlsx file, by name or 0-based index")
+	mergeSheets := fs.String("merge-sheets", "", "stack worksheets sharing a header: all, or a comma-separated list")
+	delimiter := fs.String("delimiter", "auto", "CSV field separator: auto, \",\", tab, \"|\" or \";\"")
+	encoding := fs.String("encoding", "auto", "CSV text encoding: auto, utf-8, windows-1252, latin-1, utf-16le or utf-16be")
+	ragged := fs.String("ragged", "pad", "rows with too few or too many fields: pad, truncate or reject")
+	conf := astguiclient.Flag(fs)
+	fs.Parse(os.Args[1:])
+	if fs.NArg() < 1 {
+		usage()
 		os.Exit(1)
 	}
 
-	inputFile := os.Args[1]
-	p := tea.NewProgram(initialModel(inputFile), tea.WithAltScreen())
+	inputFile := fs.Arg(0)
+	opts := extract.ReadOptions{Sheet: *sheet, MergeSheets: splitList(*mergeSheets), Delimiter: *delimiter, Encoding: *encoding, Ragged: extract.RaggedPolicy(*ragged)}
+	p := tea.NewProgram(initialModel(inputFile, *recipeFile, opts, *conf), tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error: %v", err)
 		os.Exit(1)
@@ -4,84 +4,140 @@ import (
 	"fmt"
 	"log"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
 	"etl_go/extract"
 	"etl_go/load"
-	"etl_go/transform"
-	"etl_go/types"
+	"etl_go/recipe"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 type model struct {
-	steps       []step
+	session
 	outputLines []string
 	input       string
 	width       int
 	height      int
-	dataset     *extract.DataSet
 	focused     string
 	scroll      scrollModel
-	geoStats    types.GeoStats
+	snapshots   []snapshot // undo history, see snapshots.go
+	current     int        // index of the snapshot matching session
+	readOpts    extract.ReadOptions
+	confPath    string     // --conf, carried into every new session
+	running     *stepRun   // command running in the background, see progress.go
+	cancelled   *stepRun   // cancelled run that hasn't stopped yet
+	runs        int        // background runs started, to tell their messages apart
+	grid        *gridModel // open while browsing the dataset, see grid.go
 }
 
-type step struct {
-	name   string
-	status bool
-}
-
-func initialModel(inputFile string) model {
-	steps := []step{
-		{name: "Extract CSV/XLSX", status: false},
-		{name: "Drop Columns", status: false},
-		{name: "Clean Addresses", status: false},
-		{name: "Clean Names", status: false},
-		{name: "Clean emails", status: false},
-		{name: "Clean States", status: false},
-		{name: "Normalize Phones", status: false},
-		{name: "Deduplicate Phones", status: false},
-		{name: "Populate Geo", status: false},
-		{name: "Validate States", status: false},
-		{name: "Final Validation", status: false},
-		{name: "Write CSV", status: false},
-		{name: "Write Report", status: false},
-	}
-
+func initialModel(inputFile, recipeFile string, opts extract.ReadOptions, confPath string) model {
 	// Load the initial dataset
-	var ds *extract.DataSet
-	var err error
-	ext := filepath.Ext(inputFile)
-
-	switch ext {
-	case ".csv":
-		ds, err = extract.ReadCSV(inputFile)
-	case ".xlsx":
-		ds, err = extract.ReadXLSX(inputFile)
-	default:
-		log.Fatalf("Unsupported file type: %s", ext)
-	}
+	ds, err := extract.ReadFile(inputFile, opts)
 	if err != nil {
 		log.Fatalf("Error reading file: %v", err)
 	}
 
-	outputLines := []string{
-		fmt.Sprintf("Loaded %d rows from %s", len(ds.Rows), ds.Source),
-	}
-
 	m := model{
-		steps:       steps,
-		outputLines: outputLines,
+		session:     newSession(ds),
+		outputLines: loadedLines(ds),
 		input:       "",
-		dataset:     ds,
 		focused:     "input",
 		scroll:      newScrollModel(),
+		readOpts:    opts,
+		confPath:    confPath,
+	}
+	m.session.confPath = confPath
+
+	m.pushSnapshot("load " + ds.Source)
+
+	if recipeFile != "" {
+		m.outputLines = append(m.outputLines, m.loadRecipe(recipeFile)...)
+		m.pushSnapshot("load-recipe " + filepath.Base(recipeFile))
 	}
 
 	return m
 }
 
+// loadedLines describes a freshly loaded dataset.
+func loadedLines(ds *extract.DataSet) []string {
+	loaded := fmt.Sprintf("Loaded %d rows from %s", len(ds.Rows), ds.Source)
+	if ds.Format != "" {
+		loaded += " (" + ds.Format + ")"
+	}
+	lines := []string{loaded}
+	lines = append(lines, ds.Shape.Lines()...)
+	if n := len(ds.Rejects); n > 0 {
+		lines = append(lines, fmt.Sprintf("%d ragged rows rejected; use 'rejects' to count them.", n))
+	}
+	if n := len(ds.Quarantined); n > 0 {
+		lines = append(lines, fmt.Sprintf("%d lines quarantined (could not be parsed); use 'quarantine' to view them.", n))
+	}
+	if len(ds.SheetStarts) > 1 {
+		var starts []string
+		for i, sheet := range ds.Sheets {
+			starts = append(starts, fmt.Sprintf("%s at %d", sheet, ds.SheetStarts[i]))
+		}
+		lines = append(lines, "Line numbers run on across the merged sheets; they start (header row) with "+strings.Join(starts, ", ")+".")
+	}
+	if len(ds.SkippedSheets) > 0 {
+		lines = append(lines, fmt.Sprintf("Skipped sheets (empty or different header): %s", strings.Join(ds.SkippedSheets, ", ")))
+	}
+	if strings.EqualFold(filepath.Ext(ds.Path), ".xlsx") {
+		if sheets, err := extract.ListSheets(ds.Path); err == nil && len(sheets) > 1 {
+			lines = append(lines, fmt.Sprintf("Workbook has %d sheets; use 'sheets' to list them.", len(sheets)))
+		}
+	}
+	return lines
+}
+
+// reload reads the current file again with opts, starting a fresh session.
+// The previous session stays reachable through undo.
+func (m *model) reload(opts extract.ReadOptions) {
+	ds, err := extract.ReadFile(m.dataset.Path, opts)
+	if err != nil {
+		m.outputLines = append(m.outputLines, fmt.Sprintf("Error: %v", err))
+		return
+	}
+	m.session = newSession(ds)
+	m.session.confPath = m.confPath
+	m.outputLines = append(m.outputLines, loadedLines(ds)...)
+	m.pushSnapshot("load " + ds.Source)
+}
+
+// sheetLines lists the workbook's sheets, marking the ones in use.
+func (m model) sheetLines() []string {
+	if !strings.EqualFold(filepath.Ext(m.dataset.Path), ".xlsx") {
+		return []string{"Only .xlsx workbooks have sheets."}
+	}
+	sheets, err := extract.ListSheets(m.dataset.Path)
+	if err != nil {
+		return []string{fmt.Sprintf("Error: %v", err)}
+	}
+	lines := []string{"Sheets:"}
+	for i, name := range sheets {
+		mark := " "
+		if slices.Contains(m.dataset.Sheets, name) {
+			mark = "*"
+		}
+		lines = append(lines, fmt.Sprintf(" %s [%d] %s", mark, i, name))
+	}
+	return lines
+}
+
+// splitList splits a comma-separated list, trimming each item.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func (m model) Init() tea.Cmd {
 	return nil
 }
@@ -89,10 +145,34 @@ func (m model) Init() tea.Cmd {
 // In model.go, update the Update method to ensure we return the modified model:
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case stepProgressMsg:
+		return m.updateProgress(msg)
+
+	case stepDoneMsg:
+		return m.finishRun(msg)
+
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		if m.grid != nil && msg.String() != "ctrl+c" {
+			if m.grid.Update(msg) {
+				m.grid = nil
+			}
+			return m, nil
+		}
+		key := msg.String()
+		// In the input box q is just a letter (quarantine starts with
+		// it); type exit or quit there instead
+		if key == "ctrl+c" || key == "q" && m.focused == "output" {
+			if m.running != nil {
+				m.running.cancel()
+			}
 			return m, tea.Quit
+		}
+		switch key {
+		case "esc":
+			if m.running != nil {
+				return m.cancelRun()
+			}
+			return m, nil
 		case "tab":
 			if m.focused == "input" {
 				m.focused = "output"
@@ -101,6 +181,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 		case "enter":
+			if m.running != nil {
+				m.outputLines = append(m.outputLines, "A step is still running; press Esc to cancel it.")
+				return m, nil
+			}
 			if m.focused == "input" {
 				cmd := strings.TrimSpace(m.input)
 				if cmd != "" {
@@ -163,12 +247,29 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		outputHeight := 20
 		m.scroll.SetSize(outputWidth, outputHeight)
+		if m.grid != nil {
+			m.grid.width, m.grid.height = m.width, m.height
+			m.grid.clamp()
+		}
 		return m, nil
 	}
 
 	return m, nil
 }
 
+// loadRecipe reads and applies a recipe file, returning the output lines to show.
+func (s *session) loadRecipe(path string) []string {
+	r, err := recipe.Load(path)
+	if err != nil {
+		return []string{fmt.Sprintf("Error: %v", err)}
+	}
+	lines, err := s.applyRecipe(r)
+	if err != nil {
+		return append(lines, fmt.Sprintf("Error: %v", err))
+	}
+	return append(lines, fmt.Sprintf("Applied recipe %s (%d steps).", path, len(r.Steps)))
+}
+
 func (m model) processCommand(cmd string) (tea.Model, tea.Cmd) {
 	m.outputLines = []string{}
 	m.outputLines = append(m.outputLines, fmt.Sprintf("> %s", cmd))
@@ -178,171 +279,147 @@ func (m model) processCommand(cmd string) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	switch strings.ToLower(args[0]) {
+	switch name := strings.ToLower(args[0]); name {
 	case "show":
 		previewLines := m.dataset.FirstNLines(5, m.width-35)
 		m.outputLines = append(m.outputLines, previewLines...)
+		if len(m.dataset.Rows) > 5 {
+			m.outputLines = append(m.outputLines, "Use 'browse' to page through every row.")
+		}
+
+	case "browse":
+		if len(m.dataset.Rows) == 0 {
+			m.outputLines = append(m.outputLines, "No rows to browse.")
+			break
+		}
+		m.grid = newGrid(m.dataset, m.width, m.height)
+		if len(args) > 1 {
+			m.grid.jump(strings.Join(args[1:], " "))
+			m.grid.clamp()
+		}
+
+	case "columns":
+		m.outputLines = append(m.outputLines, "Column roles:")
+		m.outputLines = append(m.outputLines, m.dataset.Schema().Describe()...)
+
+	case "help":
+		m.outputLines = append(m.outputLines, "Available commands:")
+		m.outputLines = append(m.outputLines, "show, browse, profile, columns, sheets, use-sheet, merge-sheets, map, drop, clean-address, normalize-phones, populate-geo,")
+		m.outputLines = append(m.outputLines, "validate-states, final-validate, write-csv, write-xlsx, write-report, rejects, write-rejects,")
+		m.outputLines = append(m.outputLines, "quarantine, dedup-existing, dedup-fuzzy, clusters, load-vicidial, load-recipe, save-recipe, update-geo-data, undo, redo, history, checkout, exit")
 
-	case "drop":
+	case "sheets":
+		m.outputLines = append(m.outputLines, m.sheetLines()...)
+
+	case "use-sheet":
 		if len(args) < 2 {
-			m.outputLines = append(m.outputLines, "Usage: drop <colIndex1> <colIndex2> ...")
-		} else {
-			indexes := []int{}
-			for _, s := range args[1:] {
-				i, err := strconv.Atoi(s)
-				if err == nil {
-					indexes = append(indexes, i)
-				}
-			}
-			m.dataset = transform.DropColumns(m.dataset, indexes)
-			m.steps[1].status = true
-			m.outputLines = append(m.outputLines, fmt.Sprintf("Dropped columns: %v", indexes))
+			m.outputLines = append(m.outputLines, "Usage: use-sheet <name | index>  (see 'sheets')")
+			break
 		}
+		opts := m.readOpts
+		opts.Sheet, opts.MergeSheets = strings.Join(args[1:], " "), nil
+		m.reload(opts)
+
+	case "merge-sheets":
+		sel := []string{"all"}
+		if len(args) > 1 {
+			sel = splitList(strings.Join(args[1:], " "))
+		}
+		opts := m.readOpts
+		opts.Sheet, opts.MergeSheets = "", sel
+		m.reload(opts)
 
-	case "clean-address":
-		m.dataset = transform.CleanAddresses(m.dataset)
-		m.steps[2].status = true
-		m.outputLines = append(m.outputLines, "Cleaned address fields.")
-
-	case "clean-names":
-		m.dataset = transform.CleanNames(m.dataset)
-		m.steps[3].status = true
-		m.outputLines = append(m.outputLines, "Cleaned name fields.")
-
-	case "clean-email":
-		m.dataset = transform.CleanEmails(m.dataset)
-		m.steps[4].status = true
-		m.outputLines = append(m.outputLines, "Cleaned email fields.")
-
-	case "clean-states":
-		m.dataset = transform.CleanStates(m.dataset)
-		m.steps[5].status = true
-		m.outputLines = append(m.outputLines, "Cleaned state fields (non-2-letter values cleared).")
-
-	case "normalize-phones":
-		m.dataset = transform.NormalizePhones(m.dataset)
-		m.steps[6].status = true
-		m.outputLines = append(m.outputLines, "Normalized phone numbers.")
-
-	case "dedup-phones":
-		result := transform.DedupPhones(m.dataset)
-		m.dataset = result.Cleaned
-		m.steps[7].status = true
-		m.outputLines = append(m.outputLines, fmt.Sprintf("Removed %d duplicate phone rows.", result.Duplicates))
-
-	case "populate-geo":
-		ds, stats := transform.PopulateGeo(m.dataset)
-		m.dataset = ds
-		m.geoStats = stats // Store the stats
-		m.steps[8].status = true
-		m.outputLines = append(m.outputLines, "Populated missing state/ZIP data.")
-
-	case "validate-states":
-		result := transform.ValidateStates(m.dataset)
-		m.dataset = result.Cleaned
-		m.steps[9].status = true
-		m.outputLines = append(m.outputLines, fmt.Sprintf("Removed %d invalid-state rows.", result.DropCount))
-
-	case "final-validate":
-		result := load.FinalValidate(m.dataset)
-		m.dataset = result.Cleaned
-		m.steps[10].status = true
-		m.outputLines = append(m.outputLines, fmt.Sprintf("Removed %d invalid rows (missing phone number or first and last name).", result.DropCount))
-
-	case "write-csv":
-		if err := load.WriteCSV(m.dataset, ""); err != nil {
-			m.outputLines = append(m.outputLines, fmt.Sprintf("Error writing CSV: %v", err))
-		} else {
-			m.steps[11].status = true
-			m.outputLines = append(m.outputLines, "Output CSV written successfully.")
+	case "load-recipe":
+		if len(args) < 2 {
+			m.outputLines = append(m.outputLines, "Usage: load-recipe <file.yaml | file.json>")
+			break
 		}
+		path := args[1]
+		return m.startRun("load-recipe "+filepath.Base(path), true, func(s *session) ([]string, error) {
+			return s.loadRecipe(path), nil
+		})
+
+	case "update-geo-data":
+		lines, err := updateGeoData(parseGeoDataArgs(args[1:]))
+		if err != nil {
+			m.outputLines = append(m.outputLines, fmt.Sprintf("Error: %v", err))
+			break
+		}
+		m.outputLines = append(m.outputLines, lines...)
 
-	case "write-report":
-		report := load.ReportSummary{
-			TotalProcessed: len(m.dataset.Rows),
-			FinalRowCount:  len(m.dataset.Rows),
-			GeoStats:       m.geoStats, // Add the geo stats
+	case "save-recipe":
+		path := load.OutputFileName(m.dataset.Source, "recipe", ".yaml")
+		if len(args) > 1 {
+			path = args[1]
+		}
+		r := m.recipe()
+		if len(r.Steps) == 0 {
+			m.outputLines = append(m.outputLines, "Nothing to save yet: run some steps first.")
+			break
 		}
-		reportLines := load.WriteReport(report)
-		m.outputLines = append(m.outputLines, reportLines...)
-		m.steps[12].status = true
-
-	case "clean-all":
-		// Run the entire pipeline automatically
-		m.outputLines = append(m.outputLines, "Starting automated ETL pipeline...")
-
-		// Clean addresses
-		m.dataset = transform.CleanAddresses(m.dataset)
-		m.steps[2].status = true
-		m.outputLines = append(m.outputLines, "Cleaned address fields.")
-
-		// Clean names
-		m.dataset = transform.CleanNames(m.dataset)
-		m.steps[3].status = true
-		m.outputLines = append(m.outputLines, "Cleaned name fields.")
-
-		// Clean emails
-		m.dataset = transform.CleanEmails(m.dataset)
-		m.steps[4].status = true
-		m.outputLines = append(m.outputLines, "Cleaned email fields.")
-
-		// Clean states
-		m.dataset = transform.CleanStates(m.dataset)
-		m.steps[5].status = true
-		m.outputLines = append(m.outputLines, "Cleaned state fields (non-2-letter values cleared).")
-
-		// Normalize phones
-		m.dataset = transform.NormalizePhones(m.dataset)
-		m.steps[6].status = true
-		m.outputLines = append(m.outputLines, "Normalized phone numbers.")
-
-		// Deduplicate phones
-		result := transform.DedupPhones(m.dataset)
-		m.dataset = result.Cleaned
-		m.steps[7].status = true
-		m.outputLines = append(m.outputLines, fmt.Sprintf("Removed %d duplicate phone rows.", result.Duplicates))
-
-		// Populate geo
-		ds, stats := transform.PopulateGeo(m.dataset)
-		m.dataset = ds
-		m.geoStats = stats
-		m.steps[8].status = true
-		m.outputLines = append(m.outputLines, "Populated missing state/ZIP data.")
-
-		// Validate states
-		stateResult := transform.ValidateStates(m.dataset)
-		m.dataset = stateResult.Cleaned
-		m.steps[9].status = true
-		m.outputLines = append(m.outputLines, fmt.Sprintf("Removed %d invalid-state rows.", stateResult.DropCount))
-
-		// Final validation
-		finalResult := load.FinalValidate(m.dataset)
-		m.dataset = finalResult.Cleaned
-		m.steps[10].status = true
-		m.outputLines = append(m.outputLines, fmt.Sprintf("Removed %d invalid rows (missing phone number or first and last name).", finalResult.DropCount))
-
-		// Generate report
-		report := load.ReportSummary{
-			TotalProcessed: len(m.dataset.Rows),
-			FinalRowCount:  len(m.dataset.Rows),
-			GeoStats:       m.geoStats,
+		if err := r.Save(path); err != nil {
+			m.outputLines = append(m.outputLines, fmt.Sprintf("Error: %v", err))
+			break
 		}
-		reportLines := load.WriteReport(report)
-		m.outputLines = append(m.outputLines, reportLines...)
-		m.steps[12].status = true
+		m.outputLines = append(m.outputLines, fmt.Sprintf("Saved %d steps to %s", len(r.Steps), path))
 
-		m.outputLines = append(m.outputLines, "Automated cleaning complete! Use 'write-csv' to export the final dataset.")
+	case "rejects":
+		m.outputLines = append(m.outputLines, m.rejectSummary()...)
 
-	case "help":
-		m.outputLines = append(m.outputLines, "Available commands:")
-		m.outputLines = append(m.outputLines, "show, drop, clean-address, normalize-phones, populate-geo,")
-		m.outputLines = append(m.outputLines, "validate-states, final-validate, write-csv, write-report, exit")
+	case "quarantine":
+		m.outputLines = append(m.outputLines, m.quarantineLines()...)
+
+	case "clusters":
+		m.outputLines = append(m.outputLines, m.clusterLines()...)
+
+	case "undo":
+		if m.current == 0 {
+			m.outputLines = append(m.outputLines, "Nothing to undo.")
+			break
+		}
+		undone := m.snapshots[m.current].label
+		m.checkout(m.current - 1)
+		m.outputLines = append(m.outputLines, fmt.Sprintf("Undid '%s' (%d rows).", undone, len(m.dataset.Rows)))
+
+	case "redo":
+		if m.current == len(m.snapshots)-1 {
+			m.outputLines = append(m.outputLines, "Nothing to redo.")
+			break
+		}
+		m.checkout(m.current + 1)
+		m.outputLines = append(m.outputLines, fmt.Sprintf("Redid '%s' (%d rows).", m.snapshots[m.current].label, len(m.dataset.Rows)))
+
+	case "history":
+		m.outputLines = append(m.outputLines, m.historyLines()...)
+
+	case "checkout":
+		if len(args) < 2 {
+			m.outputLines = append(m.outputLines, "Usage: checkout <n>  (see 'history')")
+			break
+		}
+		n, err := strconv.Atoi(args[1])
+		if err == nil {
+			err = m.checkout(n)
+		}
+		if err != nil {
+			m.outputLines = append(m.outputLines, fmt.Sprintf("Error: %v", err))
+			break
+		}
+		m.outputLines = append(m.outputLines, fmt.Sprintf("Restored snapshot %d '%s' (%d rows).", n, m.snapshots[n].label, len(m.dataset.Rows)))
 
 	case "exit", "quit":
 		return m, tea.Quit
 
 	default:
-		m.outputLines = append(m.outputLines, fmt.Sprintf("Unknown command: %s", cmd))
+		if !isStepName(name) {
+			m.outputLines = append(m.outputLines, fmt.Sprintf("Unknown command: %s", cmd))
+			break
+		}
+		// Steps can take minutes on a big file, so they run in the
+		// background; see progress.go.
+		return m.startRun(strings.Join(args, " "), false, func(s *session) ([]string, error) {
+			return s.runStep(name, args[1:])
+		})
 	}
 
 	m.input = ""
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"etl_go/extract"
+	"etl_go/types"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// progressChunk is the number of rows a row-local step handles between
+// progress reports and checks for cancellation.
+var progressChunk = 250_000
+
+// stepProgressMsg reports how far the running step has got. total is 0 for
+// steps that can't tell, such as the dedup steps.
+type stepProgressMsg struct {
+	run         int
+	step        string
+	done, total int
+}
+
+// stepDoneMsg carries the session a background step finished with.
+type stepDoneMsg struct {
+	run     int
+	session session
+	lines   []string
+	err     error
+}
+
+// stepRun is a command running in the background. It works on its own
+// clone of the session, which replaces the model's when the command
+// finishes, so a cancelled command leaves the dataset as it was.
+type stepRun struct {
+	id       int
+	label    string // the command as typed, for output and the snapshot
+	snapshot bool   // snapshot even when the dataset didn't change
+	cancel   context.CancelFunc
+	progress chan stepProgressMsg // latest report only; see report
+	done     chan stepDoneMsg
+
+	last        stepProgressMsg
+	stepStarted time.Time
+	committed   int // session.committed when the run started
+}
+
+// context returns the context steps should stop on; it is only cancelled
+// while the TUI runs a step in the background.
+func (s *session) context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+// reportProgress passes a step's progress to the TUI, if it is listening.
+func (s *session) reportProgress(step string, done, total int) {
+	if s.progress != nil {
+		s.progress(step, done, total)
+	}
+}
+
+// chunked runs a row-local transform over the dataset progressChunk rows at
+// a time, reporting progress and checking for cancellation between chunks.
+// The chunks' rows and rejects are joined in order, so the result is the
+// same as running fn over the whole dataset.
+func (s *session) chunked(step string, fn func(part *extract.DataSet) (*extract.DataSet, []types.Reject, error)) (*extract.DataSet, []types.Reject, error) {
+	ds, ctx := s.dataset, s.context()
+	total := len(ds.Rows)
+	s.reportProgress(step, 0, total)
+	if total <= progressChunk {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		out, rejects, err := fn(ds)
+		s.reportProgress(step, total, total)
+		return out, rejects, err
+	}
+
+	rows := make([][]string, 0, total)
+	lines := make([]int, 0, total)
+	var rejects []types.Reject
+	for start := 0; start < total; start += progressChunk {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		end := min(start+progressChunk, total)
+		partLines := make([]int, 0, end-start)
+		for i := start; i < end; i++ {
+			partLines = append(partLines, ds.Line(i))
+		}
+		out, dropped, err := fn(ds.WithRows(ds.Rows[start:end], partLines))
+		if err != nil {
+			return nil, nil, err
+		}
+		rows = append(rows, out.Rows...)
+		lines = append(lines, out.Lines...)
+		rejects = append(rejects, dropped...)
+		s.reportProgress(step, end, total)
+	}
+	return ds.WithRows(rows, lines), rejects, nil
+}
+
+// withoutRejects adapts a transform that never drops rows for chunked.
+func withoutRejects(fn func(*extract.DataSet) (*extract.DataSet, error)) func(*extract.DataSet) (*extract.DataSet, []types.Reject, error) {
+	return func(part *extract.DataSet) (*extract.DataSet, []types.Reject, error) {
+		out, err := fn(part)
+		return out, nil, err
+	}
+}
+
+// startRun runs fn against a clone of the session in the background and
+// returns the command that waits for its first message. snapshot records a
+// snapshot afterwards even if the dataset didn't change.
+func (m model) startRun(label string, snapshot bool, fn func(s *session) ([]string, error)) (tea.Model, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.runs++
+	r := &stepRun{
+		id:          m.runs,
+		label:       label,
+		snapshot:    snapshot,
+		cancel:      cancel,
+		progress:    make(chan stepProgressMsg, 1),
+		done:        make(chan stepDoneMsg, 1),
+		stepStarted: time.Now(),
+		committed:   m.committed,
+	}
+	m.running = r
+	m.input = ""
+
+	s := m.session.clone()
+	s.ctx = ctx
+	s.progress = func(step string, done, total int) {
+		r.report(stepProgressMsg{run: r.id, step: step, done: done, total: total})
+	}
+	go func() {
+		lines, err := fn(&s)
+		s.ctx, s.progress = nil, nil
+		r.done <- stepDoneMsg{run: r.id, session: s, lines: lines, err: err}
+	}()
+	return m, r.wait()
+}
+
+// report replaces any progress the TUI hasn't picked up yet with p, so a
+// fast step never waits on the UI.
+func (r *stepRun) report(p stepProgressMsg) {
+	select {
+	case <-r.progress:
+	default:
+	}
+	r.progress <- p
+}
+
+// wait returns the command that delivers the run's next message.
+func (r *stepRun) wait() tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case p := <-r.progress:
+			return p
+		case d := <-r.done:
+			return d
+		}
+	}
+}
+
+// updateProgress records a progress report from the running step.
+func (m model) updateProgress(msg stepProgressMsg) (tea.Model, tea.Cmd) {
+	if m.cancelled != nil && msg.run == m.cancelled.id {
+		return m, m.cancelled.wait() // keep waiting for it to stop
+	}
+	if m.running == nil || msg.run != m.running.id {
+		return m, nil
+	}
+	if msg.step != m.running.last.step {
+		m.running.stepStarted = time.Now()
+	}
+	m.running.last = msg
+	return m, m.running.wait()
+}
+
+// finishRun takes the session a background run finished with. As with a
+// step run directly, a failed step has already left its dataset untouched.
+func (m model) finishRun(msg stepDoneMsg) (tea.Model, tea.Cmd) {
+	if m.cancelled != nil && msg.run == m.cancelled.id {
+		return m.finishCancelled(msg)
+	}
+	if m.running == nil || msg.run != m.running.id {
+		return m, nil
+	}
+	r := m.running
+	m.running = nil
+	r.cancel()
+
+	m.outputLines = append(m.outputLines, msg.lines...)
+	if msg.err != nil {
+		m.outputLines = append(m.outputLines, fmt.Sprintf("Error: %v", msg.err))
+	}
+	before := m.dataset
+	m.session = msg.session
+	// Only steps that changed the data get a snapshot; writes don't.
+	if r.snapshot || m.dataset != before {
+		m.pushSnapshot(r.label)
+	}
+	return m, nil
+}
+
+// cancelRun stops the running step. Its clone of the session is dropped,
+// so the dataset stays as it was before the step. Batches load-vicidial
+// committed before it stopped can't be taken back; finishCancelled reports
+// them once the run has stopped.
+func (m model) cancelRun() (tea.Model, tea.Cmd) {
+	r := m.running
+	m.running = nil
+	m.cancelled = r
+	r.cancel()
+	m.outputLines = append(m.outputLines, fmt.Sprintf("Cancelled '%s'; the dataset is unchanged.", r.label))
+	return m, nil
+}
+
+// finishCancelled takes the last message of a cancelled run, dropping its
+// session but reporting rows it had already inserted into vicidial_list.
+func (m model) finishCancelled(msg stepDoneMsg) (tea.Model, tea.Cmd) {
+	r := m.cancelled
+	m.cancelled = nil
+	if n := msg.session.committed - r.committed; n > 0 {
+		m.committed += n
+		m.outputLines = append(m.outputLines, fmt.Sprintf("'%s' had already inserted %d rows into vicidial_list before it stopped; they stay in the database.", r.label, n))
+	}
+	return m, nil
+}
+
+// renderProgress draws the running step's progress bar for the checklist
+// panel.
+func (r *stepRun) renderProgress(width int) []string {
+	p := r.last
+	step := p.step
+	if step == "" {
+		step = r.label
+	}
+	lines := []string{titleStyle.Render("Running " + step)}
+	if p.total == 0 {
+		return append(lines, "working...", pendingStyle.Render("Esc to cancel"))
+	}
+
+	barWidth := width - 5
+	filled := barWidth * p.done / p.total
+	lines = append(lines,
+		fmt.Sprintf("%s%s %3d%%", strings.Repeat("█", filled), strings.Repeat("░", barWidth-filled), 100*p.done/p.total),
+		fmt.Sprintf("%d/%d rows", p.done, p.total),
+	)
+	if elapsed := time.Since(r.stepStarted).Seconds(); p.done > 0 && elapsed > 0 {
+		rate := float64(p.done) / elapsed
+		eta := time.Duration(float64(p.total-p.done) / rate * float64(time.Second)).Round(time.Second)
+		lines = append(lines, fmt.Sprintf("%.0f rows/s, ETA %s", rate, eta))
+	}
+	return append(lines, pendingStyle.Render("Esc to cancel"))
+}
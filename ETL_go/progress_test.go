@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// withProgressChunk runs fn with progressChunk set to n.
+func withProgressChunk(n int, fn func()) {
+	old := progressChunk
+	progressChunk = n
+	defer func() { progressChunk = old }()
+	fn()
+}
+
+func TestChunkedStepsMatchWholeDataset(t *testing.T) {
+	base := testModel().session
+	for range 4 {
+		base.dataset.Rows = append(base.dataset.Rows, base.dataset.Rows...)
+	}
+	base.dataset.Rows[5] = []string{"Bad", "Phone", "1 Elm", "ZZ", "", "0001112222", ""}
+
+	steps := []string{"clean-address", "clean-names", "normalize-phones", "dedup-phones", "populate-geo", "validate-states", "final-validate"}
+	runAll := func() session {
+		s := base.clone()
+		for _, name := range steps {
+			if _, err := s.execute(name, nil); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+		return s
+	}
+
+	whole := runAll()
+	var chunked session
+	withProgressChunk(3, func() { chunked = runAll() })
+
+	if !reflect.DeepEqual(whole.dataset.Rows, chunked.dataset.Rows) || !reflect.DeepEqual(whole.dataset.Lines, chunked.dataset.Lines) {
+		t.Errorf("chunked rows differ:\nwhole   %v\nchunked %v", whole.dataset.Lines, chunked.dataset.Lines)
+	}
+	if !reflect.DeepEqual(whole.rejects, chunked.rejects) {
+		t.Errorf("chunked rejects differ: %d vs %d", len(whole.rejects), len(chunked.rejects))
+	}
+	if whole.nameStats != chunked.nameStats || whole.geoStats != chunked.geoStats {
+		t.Errorf("chunked stats differ: %+v %+v vs %+v %+v", whole.nameStats, whole.geoStats, chunked.nameStats, chunked.geoStats)
+	}
+	if len(whole.rejects) == 0 {
+		t.Error("expected the fixture to drop a row")
+	}
+}
+
+func TestChunkedReportsProgressAndStopsWhenCancelled(t *testing.T) {
+	s := testModel().session.clone()
+	for range 3 {
+		s.dataset.Rows = append(s.dataset.Rows, s.dataset.Rows...)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var reports [][2]int
+	s.ctx = ctx
+	s.progress = func(step string, done, total int) {
+		reports = append(reports, [2]int{done, total})
+		if done >= 6 {
+			cancel()
+		}
+	}
+
+	withProgressChunk(3, func() {
+		_, err := s.execute("clean-names", nil)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	})
+	want := [][2]int{{0, 0}, {0, 16}, {3, 16}, {6, 16}}
+	if !reflect.DeepEqual(reports, want) {
+		t.Errorf("progress reports = %v, want %v", reports, want)
+	}
+	if s.steps[3].status {
+		t.Error("a cancelled step should not be checked off")
+	}
+}
+
+func TestEscCancelsRunningStep(t *testing.T) {
+	m := testModel()
+	before := m.dataset
+
+	started := make(chan struct{})
+	next, wait := m.startRun("slow-step", false, func(s *session) ([]string, error) {
+		s.dataset = nil
+		close(started)
+		<-s.context().Done()
+		return nil, s.context().Err()
+	})
+	<-started
+	m = next.(model)
+	if m.running == nil {
+		t.Fatal("expected a running step")
+	}
+
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = next.(model)
+	if m.running != nil {
+		t.Error("Esc should stop the running step")
+	}
+	if m.dataset != before {
+		t.Error("Esc should leave the dataset as it was before the step")
+	}
+
+	// The step's result arrives late and is ignored
+	next, _ = m.Update(wait())
+	m = next.(model)
+	if m.dataset != before || len(m.snapshots) != 1 {
+		t.Errorf("late result was applied: %d snapshots", len(m.snapshots))
+	}
+}
+
+func TestCancelReportsRowsAlreadyLoaded(t *testing.T) {
+	m := testModel()
+
+	started := make(chan struct{})
+	next, wait := m.startRun("load-vicidial 101", false, func(s *session) ([]string, error) {
+		s.committed += 500 // first batch committed
+		close(started)
+		<-s.context().Done()
+		return nil, s.context().Err()
+	})
+	<-started
+	next, _ = next.(model).Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = next.(model)
+
+	next, _ = m.Update(wait())
+	m = next.(model)
+	want := "'load-vicidial 101' had already inserted 500 rows into vicidial_list before it stopped; they stay in the database."
+	if got := m.outputLines[len(m.outputLines)-1]; got != want {
+		t.Errorf("last line = %q, want %q", got, want)
+	}
+	if m.cancelled != nil || m.running != nil {
+		t.Error("the cancelled run should be finished")
+	}
+}
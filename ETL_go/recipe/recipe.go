@@ -0,0 +1,127 @@
+package recipe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"etl_go/extract"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the recipe format written by Save. Load accepts any
+// version from 1 up to this one.
+const CurrentVersion = 1
+
+// Recipe is a saved pipeline: the column roles to pin and the steps to run,
+// in order, exactly as they would be typed into the TUI.
+type Recipe struct {
+	Version int               `json:"version" yaml:"version"`
+	Name    string            `json:"name,omitempty" yaml:"name,omitempty"`
+	Columns map[string]string `json:"columns,omitempty" yaml:"columns,omitempty"` // role -> header name
+	Steps   []Step            `json:"steps" yaml:"steps"`
+}
+
+// Step is one command and its arguments, e.g. {Name: "drop", Args: ["0", "5", "9"]}.
+type Step struct {
+	Name string   `json:"name" yaml:"name"`
+	Args []string `json:"args,omitempty" yaml:"args,omitempty,flow"`
+}
+
+// String renders the step the way it would be typed in the TUI.
+func (s Step) String() string {
+	return strings.TrimSpace(s.Name + " " + strings.Join(s.Args, " "))
+}
+
+// Load reads a recipe from a .yaml/.yml or .json file. It does not validate
+// step names; call Validate before running it.
+func Load(path string) (*Recipe, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recipe: %w", err)
+	}
+
+	var r Recipe
+	if isJSON(path) {
+		err = json.Unmarshal(data, &r)
+	} else {
+		err = yaml.Unmarshal(data, &r)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse recipe %s: %w", filepath.Base(path), err)
+	}
+	return &r, nil
+}
+
+// Save writes the recipe as JSON when path ends in .json and as YAML otherwise.
+func (r *Recipe) Save(path string) error {
+	var (
+		data []byte
+		err  error
+	)
+	if isJSON(path) {
+		data, err = json.MarshalIndent(r, "", "  ")
+		data = append(data, '\n')
+	} else {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		err = enc.Encode(r)
+		data = buf.Bytes()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode recipe: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write recipe: %w", err)
+	}
+	return nil
+}
+
+// Validate checks the version, that every column key is a known role, and
+// that every step name is accepted by known. All problems are reported at once.
+func (r *Recipe) Validate(known func(name string) bool) error {
+	var problems []string
+
+	if r.Version < 1 || r.Version > CurrentVersion {
+		problems = append(problems, fmt.Sprintf("unsupported version %d (this build reads 1-%d)", r.Version, CurrentVersion))
+	}
+	for role := range r.Columns {
+		if _, err := extract.ParseRole(role); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(r.Steps) == 0 {
+		problems = append(problems, "no steps")
+	}
+	for i, s := range r.Steps {
+		if !known(s.Name) {
+			problems = append(problems, fmt.Sprintf("step %d: unknown step %q", i+1, s.Name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid recipe: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Mapping converts Columns to the form DataSet.Mapping uses.
+// Validate must have accepted the recipe first.
+func (r *Recipe) Mapping() map[extract.Role]string {
+	mapping := make(map[extract.Role]string, len(r.Columns))
+	for role, header := range r.Columns {
+		parsed, _ := extract.ParseRole(role)
+		mapping[parsed] = header
+	}
+	return mapping
+}
+
+func isJSON(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
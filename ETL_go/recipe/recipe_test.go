@@ -0,0 +1,57 @@
+package recipe
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func knownSteps(name string) bool {
+	return name == "drop" || name == "clean-all"
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	r := &Recipe{
+		Version: CurrentVersion,
+		Name:    "vendorA",
+		Columns: map[string]string{"phone": "Cell"},
+		Steps: []Step{
+			{Name: "drop", Args: []string{"0", "5", "9"}},
+			{Name: "clean-all"},
+		},
+	}
+
+	for _, name := range []string{"vendorA.yaml", "vendorA.json"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := r.Save(path); err != nil {
+			t.Fatalf("%s: save failed: %v", name, err)
+		}
+		got, err := Load(path)
+		if err != nil {
+			t.Fatalf("%s: load failed: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, r) {
+			t.Errorf("%s: expected %+v, got %+v", name, r, got)
+		}
+		if err := got.Validate(knownSteps); err != nil {
+			t.Errorf("%s: unexpected validation error: %v", name, err)
+		}
+	}
+}
+
+func TestValidateRejectsBadRecipes(t *testing.T) {
+	tests := []struct {
+		name string
+		r    Recipe
+	}{
+		{"future version", Recipe{Version: CurrentVersion + 1, Steps: []Step{{Name: "clean-all"}}}},
+		{"unknown step", Recipe{Version: 1, Steps: []Step{{Name: "scrub"}}}},
+		{"unknown role", Recipe{Version: 1, Columns: map[string]string{"fax": "Fax"}, Steps: []Step{{Name: "clean-all"}}}},
+		{"no steps", Recipe{Version: 1}},
+	}
+	for _, tt := range tests {
+		if err := tt.r.Validate(knownSteps); err == nil {
+			t.Errorf("%s: expected a validation error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,771 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"etl_go/extract"
+	"etl_go/load"
+	"etl_go/recipe"
+	"etl_go/transform"
+	"etl_go/types"
+
+	"astguiclient"
+	"leadkit/geodata"
+)
+
+// session holds the pipeline state shared by the TUI and the batch runner:
+// the current dataset, which checklist steps have run, and collected stats.
+type session struct {
+	dataset     *extract.DataSet
+	steps       []step
+	initialRows int // rows in the file as loaded
+	nameStats   types.NameStats
+	geoStats    types.GeoStats
+	stepStats   []load.StepStats         // row counts around each transform that ran
+	rejects     []types.Reject           // every row dropped so far, for write-rejects
+	history     []recipe.Step            // successful commands, for save-recipe
+	confPath    string                   // astguiclient.conf from --conf; "" uses the default
+	clusters    []transform.FuzzyCluster // from the last dedup-fuzzy, for the clusters command
+	noReports   bool                     // write-report without a file only prints; batch runs use --report
+	committed   int                      // rows load-vicidial has inserted, even if it then failed
+
+	// Set while the TUI runs a step in the background; see progress.go.
+	ctx      context.Context
+	progress func(step string, done, total int)
+}
+
+type step struct {
+	name   string
+	status bool
+}
+
+// errUnknownStep is returned by runStep for names it doesn't handle.
+var errUnknownStep = errors.New("unknown step")
+
+func newSession(ds *extract.DataSet) session {
+	steps := []step{
+		{name: "Extract CSV/XLSX", status: false},
+		{name: "Drop Columns", status: false},
+		{name: "Clean Addresses", status: false},
+		{name: "Clean Names", status: false},
+		{name: "Clean emails", status: false},
+		{name: "Clean States", status: false},
+		{name: "Normalize Phones", status: false},
+		{name: "Deduplicate Phones", status: false},
+		{name: "Populate Geo", status: false},
+		{name: "Validate States", status: false},
+		{name: "Final Validation", status: false},
+		{name: "Write CSV/XLSX", status: false},
+		{name: "Write Report", status: false},
+	}
+
+	// Rows rejected while reading still count as processed, and removed
+	return session{
+		dataset:     ds,
+		steps:       steps,
+		initialRows: len(ds.Rows) + len(ds.Rejects),
+		rejects:     slices.Clone(ds.Rejects),
+	}
+}
+
+// cleanAllSteps is the order clean-all runs the pipeline in.
+var cleanAllSteps = []string{
+	"clean-address", "clean-names", "clean-email", "clean-states", "normalize-phones",
+	"dedup-phones", "populate-geo", "validate-states", "final-validate", "write-report",
+}
+
+// countsRows reports whether a step's row counts go in the report: every
+// step that can drop rows, whether or not clean-all ran it.
+func countsRows(name string) bool {
+	switch name {
+	case "dedup-existing", "dedup-fuzzy":
+		return true
+	case "write-report":
+		return false
+	}
+	return slices.Contains(cleanAllSteps, name)
+}
+
+// stepNames lists every command runStep accepts.
+var stepNames = append([]string{"drop", "map", "write-csv", "write-xlsx", "write-rejects", "dedup-existing", "dedup-fuzzy", "load-vicidial", "profile", "clean-all"}, cleanAllSteps...)
+
+func isStepName(name string) bool {
+	for _, n := range stepNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// runStep runs a single pipeline step against the current dataset, marks it
+// done in the checklist and returns its output lines. The lines are valid
+// even when an error is returned; the dataset is left as it was before the
+// failing step. Successful steps are added to the session history.
+func (s *session) runStep(name string, args []string) ([]string, error) {
+	lines, err := s.execute(name, args)
+	if err == nil {
+		s.history = append(s.history, recipe.Step{Name: name, Args: args})
+	}
+	return lines, err
+}
+
+// execute does the work of runStep without recording history.
+func (s *session) execute(name string, args []string) ([]string, error) {
+	var lines []string
+	rowsIn := len(s.dataset.Rows)
+	s.reportProgress(name, 0, 0)
+
+	switch name {
+	case "drop":
+		if len(args) < 1 {
+			return nil, fmt.Errorf("usage: drop <colIndex1> <colIndex2> ...")
+		}
+		indexes := []int{}
+		for _, a := range args {
+			i, err := strconv.Atoi(a)
+			if err != nil {
+				return nil, fmt.Errorf("invalid column index: %s", a)
+			}
+			indexes = append(indexes, i)
+		}
+		s.dataset = transform.DropColumns(s.dataset, indexes)
+		s.steps[1].status = true
+		lines = append(lines, fmt.Sprintf("Dropped columns: %v", indexes))
+
+	case "map":
+		if len(args) < 2 {
+			return nil, fmt.Errorf("usage: map <role> <colIndex | header name>")
+		}
+		role, err := extract.ParseRole(args[0])
+		if err != nil {
+			return nil, err
+		}
+		header := strings.Join(args[1:], " ")
+		if i, err := strconv.Atoi(header); err == nil {
+			if i < 0 || i >= len(s.dataset.Headers) {
+				return nil, fmt.Errorf("column index %d out of range", i)
+			}
+			header = s.dataset.Headers[i]
+		}
+		s.dataset = s.dataset.MapColumn(role, header)
+		lines = append(lines, fmt.Sprintf("Mapped %s -> %s", role, header))
+
+	case "clean-address":
+		ds, _, err := s.chunked(name, withoutRejects(transform.CleanAddresses))
+		if err != nil {
+			return nil, err
+		}
+		s.dataset = ds
+		s.steps[2].status = true
+		lines = append(lines, "Cleaned address fields.")
+
+	case "clean-names":
+		var stats types.NameStats
+		ds, _, err := s.chunked(name, func(part *extract.DataSet) (*extract.DataSet, []types.Reject, error) {
+			cleaned, partStats, err := transform.CleanNames(part)
+			stats.Add(partStats)
+			return cleaned, nil, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		s.dataset = ds
+		s.nameStats.Add(stats)
+		s.steps[3].status = true
+		lines = append(lines, "Cleaned name fields.")
+
+	case "clean-email":
+		ds, _, err := s.chunked(name, withoutRejects(transform.CleanEmails))
+		if err != nil {
+			return nil, err
+		}
+		s.dataset = ds
+		s.steps[4].status = true
+		lines = append(lines, "Cleaned email fields.")
+
+	case "clean-states":
+		ds, _, err := s.chunked(name, withoutRejects(transform.CleanStates))
+		if err != nil {
+			return nil, err
+		}
+		s.dataset = ds
+		s.steps[5].status = true
+		lines = append(lines, "Cleaned state fields (non-2-letter values cleared).")
+
+	case "normalize-phones":
+		unknown := 0
+		ds, dropped, err := s.chunked(name, func(part *extract.DataSet) (*extract.DataSet, []types.Reject, error) {
+			out, rejects, err := transform.NormalizePhones(part)
+			if err == nil {
+				unknown += transform.UnknownAreaCodes(out)
+			}
+			return out, rejects, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		s.dataset = ds
+		s.rejects = append(s.rejects, dropped...)
+		s.steps[6].status = true
+		lines = append(lines, fmt.Sprintf("Normalized phone numbers; removed %d invalid numbers.", len(dropped)))
+		if unknown > 0 {
+			lines = append(lines, fmt.Sprintf("Kept %d numbers whose area code isn't in the area code table (version %s); 'update-geo-data area-codes <file>' loads a newer one.", unknown, geodata.AreaCodes().Version))
+		}
+
+	case "dedup-phones":
+		// One deduper for every chunk, so duplicates are found across chunks
+		fn, err := transform.PhoneDeduper(s.dataset.Schema())
+		if err != nil {
+			return nil, err
+		}
+		cleaned, dropped, err := s.chunked(name, func(part *extract.DataSet) (*extract.DataSet, []types.Reject, error) {
+			out, rejects := transform.ApplyRows(part, fn)
+			return out, rejects, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		s.dataset = cleaned
+		s.rejects = append(s.rejects, dropped...)
+		s.steps[7].status = true
+		lines = append(lines, fmt.Sprintf("Removed %d duplicate phone rows.", len(dropped)))
+
+	case "dedup-existing":
+		set, setLines, err := existingPhones(s.context(), args, s.confPath)
+		if err != nil {
+			return nil, err
+		}
+		cleaned, dropped, err := s.chunked(name, func(part *extract.DataSet) (*extract.DataSet, []types.Reject, error) {
+			return transform.DedupExisting(part, set)
+		})
+		if err != nil {
+			return nil, err
+		}
+		s.dataset = cleaned
+		s.rejects = append(s.rejects, dropped...)
+		dnc := 0
+		for _, r := range dropped {
+			if r.Code() == types.ReasonDNC {
+				dnc++
+			}
+		}
+		lines = append(lines, setLines...)
+		lines = append(lines, fmt.Sprintf("Removed %d rows: %d do-not-call, %d already loaded.", len(dropped), dnc, len(dropped)-dnc))
+
+	case "dedup-fuzzy":
+		opts, err := transform.ParseFuzzyOptions(args)
+		if err != nil {
+			return nil, err
+		}
+		result, err := transform.DedupFuzzy(s.context(), s.dataset, opts)
+		if err != nil {
+			return nil, err
+		}
+		s.dataset = result.Cleaned
+		s.rejects = append(s.rejects, result.Dropped...)
+		s.clusters = result.Clusters
+		matched := 0
+		for _, c := range result.Clusters {
+			matched += len(c.Members)
+		}
+		lines = append(lines, fmt.Sprintf("Scored %d candidate pairs (blocked by %s, threshold %.2f).", result.Pairs, opts.Block, opts.Threshold))
+		if result.SkippedBlocks > 0 {
+			lines = append(lines, fmt.Sprintf("Skipped %d blocks too large to compare; try block=last.", result.SkippedBlocks))
+		}
+		if opts.Merge {
+			lines = append(lines, fmt.Sprintf("Merged %d rows into %d clusters; use 'clusters' to review.", matched, len(result.Clusters)))
+		} else {
+			lines = append(lines, fmt.Sprintf("Flagged %d rows in %d clusters; use 'clusters' to review, or rerun with merge.", matched, len(result.Clusters)))
+		}
+
+	case "populate-geo":
+		opts, err := transform.ParseGeoOptions(args)
+		if err != nil {
+			return nil, err
+		}
+		var stats types.GeoStats
+		ds, _, err := s.chunked(name, func(part *extract.DataSet) (*extract.DataSet, []types.Reject, error) {
+			cleaned, partStats, err := transform.PopulateGeo(part, opts)
+			stats.Add(partStats)
+			return cleaned, nil, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		s.dataset = ds
+		s.geoStats.Add(stats)
+		s.steps[8].status = true
+		lines = append(lines, "Populated missing state/ZIP data.")
+		if zips := geodata.Zips(); zips.Seed() {
+			lines = append(lines, fmt.Sprintf("Note: only the built-in seed ZIP table (%d ZIPs) is loaded; %d states were guessed from ZIP ranges. Build the full ZIP5 file with leadkit's mkzips and load it with 'update-geo-data zips <file>' or 'run --zip-data <file>'.", zips.Len(), stats.StateFromZipRange))
+		}
+
+	case "validate-states":
+		ds, dropped, err := s.chunked(name, func(part *extract.DataSet) (*extract.DataSet, []types.Reject, error) {
+			result, err := transform.ValidateStates(part)
+			if err != nil {
+				return nil, nil, err
+			}
+			return result.Cleaned, result.Dropped, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		s.dataset = ds
+		s.rejects = append(s.rejects, dropped...)
+		s.steps[9].status = true
+		lines = append(lines, fmt.Sprintf("Removed %d invalid-state rows.", len(dropped)))
+
+	case "final-validate":
+		ds, dropped, err := s.chunked(name, func(part *extract.DataSet) (*extract.DataSet, []types.Reject, error) {
+			result, err := load.FinalValidate(part)
+			if err != nil {
+				return nil, nil, err
+			}
+			return result.Cleaned, result.Dropped, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		s.dataset = ds
+		s.rejects = append(s.rejects, dropped...)
+		s.steps[10].status = true
+		lines = append(lines, fmt.Sprintf("Removed %d invalid rows (missing phone number or first and last name).", len(dropped)))
+
+	case "profile":
+		// profile [column] [json | file.json]: summarize every column, or
+		// one in detail, optionally saving the whole profile as JSON
+		p, err := transform.ProfileDataSet(s.dataset)
+		if err != nil {
+			return nil, err
+		}
+		outFile, column := "", ""
+		for _, a := range args {
+			switch {
+			case strings.EqualFold(a, "json"):
+				outFile = load.OutputFileName(s.dataset.Source, "profile", ".json")
+			case strings.EqualFold(filepath.Ext(a), ".json"):
+				outFile = a
+			default:
+				column = strings.TrimSpace(column + " " + a)
+			}
+		}
+		if column == "" {
+			lines = append(lines, p.Lines()...)
+			lines = append(lines, "Use 'profile <column>' for a column's top values.")
+		} else {
+			j, err := s.columnIndex(column)
+			if err != nil {
+				return nil, err
+			}
+			lines = append(lines, p.Columns[j].Lines()...)
+		}
+		if outFile != "" {
+			if err := load.SaveProfile(p, outFile); err != nil {
+				return nil, err
+			}
+			lines = append(lines, fmt.Sprintf("Profile saved to %s", outFile))
+		}
+
+	case "write-csv":
+		outFile := load.CleanedFileName(s.dataset.Source)
+		if len(args) > 0 {
+			outFile = args[0]
+		}
+		if err := load.WriteCSV(s.dataset, outFile); err != nil {
+			return nil, fmt.Errorf("writing CSV: %w", err)
+		}
+		s.steps[11].status = true
+		lines = append(lines, fmt.Sprintf("Output CSV written successfully: %d rows to %s", len(s.dataset.Rows), outFile))
+
+	case "write-xlsx":
+		// write-xlsx [file] [report]: "report" adds a sheet with the summary
+		// report and rejected rows
+		outFile := load.OutputFileName(s.dataset.Source, "cleaned", ".xlsx")
+		var opts load.XLSXOptions
+		for _, a := range args {
+			if strings.EqualFold(a, "report") {
+				report := s.report()
+				opts = load.XLSXOptions{Report: &report, Rejects: s.rejects}
+				continue
+			}
+			outFile = a
+		}
+		if err := load.WriteXLSX(s.dataset, outFile, opts); err != nil {
+			return nil, fmt.Errorf("writing XLSX: %w", err)
+		}
+		s.steps[11].status = true
+		lines = append(lines, fmt.Sprintf("Output XLSX written successfully: %d rows to %s", len(s.dataset.Rows), outFile))
+
+	case "write-rejects":
+		// Accepts a format ("csv", "xlsx") or a file name
+		outFile := load.OutputFileName(s.dataset.Source, "rejects", ".csv")
+		if len(args) > 0 {
+			switch strings.ToLower(args[0]) {
+			case "csv":
+			case "xlsx":
+				outFile = load.OutputFileName(s.dataset.Source, "rejects", ".xlsx")
+			default:
+				outFile = args[0]
+			}
+		}
+		if err := load.WriteRejects(s.rejects, outFile); err != nil {
+			return nil, fmt.Errorf("writing rejects: %w", err)
+		}
+		lines = append(lines, fmt.Sprintf("Wrote %d rejected rows to %s", len(s.rejects), outFile))
+
+	case "load-vicidial":
+		opts, confPath, err := parseVicidialArgs(args, s.confPath)
+		if err != nil {
+			return nil, err
+		}
+		ctx := s.context()
+		var db *sql.DB
+		if !opts.DryRun {
+			if db, err = astguiclient.Open(ctx, confPath); err != nil {
+				return nil, err
+			}
+			defer db.Close()
+		}
+		result, err := load.LoadVicidial(ctx, db, s.dataset, opts)
+		s.committed += result.Inserted
+		if err != nil {
+			return nil, fmt.Errorf("load-vicidial: %w", err)
+		}
+		lines = append(lines, result.Lines(opts)...)
+
+	case "write-report":
+		return s.writeReport(args, !s.noReports)
+
+	case "clean-all":
+		// Run the entire pipeline automatically
+		lines = append(lines, "Starting automated ETL pipeline...")
+		for _, name := range cleanAllSteps {
+			if err := s.context().Err(); err != nil {
+				return lines, err
+			}
+			var stepLines []string
+			var err error
+			if name == "write-report" {
+				// Only printed; saving it is up to an explicit write-report
+				stepLines, err = s.writeReport(nil, false)
+			} else {
+				stepLines, err = s.execute(name, nil)
+			}
+			lines = append(lines, stepLines...)
+			if err != nil {
+				lines = append(lines, "Automated cleaning stopped. Check the column roles with 'columns' and 'map'.")
+				return lines, err
+			}
+		}
+		lines = append(lines, "Automated cleaning complete! Use 'write-csv' to export the final dataset.")
+
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnknownStep, name)
+	}
+
+	if countsRows(name) {
+		s.stepStats = append(s.stepStats, load.StepStats{Name: name, RowsIn: rowsIn, RowsOut: len(s.dataset.Rows)})
+	}
+	return lines, nil
+}
+
+// writeReport prints the report and saves it to the file in args, or with
+// none and save set, as text, JSON and HTML next to the source.
+func (s *session) writeReport(args []string, save bool) ([]string, error) {
+	report := s.report()
+	lines := load.WriteReport(report)
+	var paths []string
+	switch {
+	case len(args) > 0:
+		paths = args[:1]
+	case save:
+		paths = []string{
+			load.OutputFileName(s.dataset.Source, "report", ".txt"),
+			load.OutputFileName(s.dataset.Source, "report", ".json"),
+			load.OutputFileName(s.dataset.Source, "report", ".html"),
+		}
+	}
+	for _, path := range paths {
+		if err := load.SaveReport(report, path); err != nil {
+			return lines, err
+		}
+	}
+	s.steps[12].status = true
+	if len(paths) > 0 {
+		lines = append(lines, fmt.Sprintf("Report saved to %s", strings.Join(paths, ", ")))
+	}
+	return lines, nil
+}
+
+// report builds the summary report for the current session.
+func (s *session) report() load.ReportSummary {
+	report := load.ReportSummary{
+		Source:           s.dataset.Source,
+		TotalProcessed:   s.initialRows,
+		QuarantinedLines: len(s.dataset.Quarantined),
+		NameStats:        s.nameStats,
+		GeoStats:         s.geoStats,
+		Steps:            s.stepStats,
+		AreaCodeVersion:  geodata.AreaCodes().Version,
+		ZipVersion:       geodata.Zips().Version,
+		FinalRowCount:    len(s.dataset.Rows),
+	}
+	report.AddRejects(s.rejects)
+	return report
+}
+
+// applyRecipe validates r, pins its column mapping and runs its steps in
+// order, stopping at the first failure.
+func (s *session) applyRecipe(r *recipe.Recipe) ([]string, error) {
+	if err := r.Validate(isStepName); err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for role, header := range r.Mapping() {
+		s.dataset = s.dataset.MapColumn(role, header)
+	}
+	for _, st := range r.Steps {
+		lines = append(lines, fmt.Sprintf("> %s", st))
+		stepLines, err := s.runStep(st.Name, st.Args)
+		lines = append(lines, stepLines...)
+		if err != nil {
+			return lines, fmt.Errorf("recipe step %q: %w", st, err)
+		}
+	}
+	return lines, nil
+}
+
+// recipe captures the session's column mapping and transform history.
+// Column mappings are saved under columns rather than as map steps, and
+// write and profile steps are left out since their output belongs to this run.
+func (s *session) recipe() *recipe.Recipe {
+	r := &recipe.Recipe{
+		Version: recipe.CurrentVersion,
+		Name:    s.dataset.Source,
+		Columns: make(map[string]string),
+	}
+	for role, header := range s.dataset.Mapping {
+		r.Columns[string(role)] = header
+	}
+	for _, st := range s.history {
+		switch st.Name {
+		case "map", "write-csv", "write-xlsx", "write-rejects", "write-report", "load-vicidial", "profile":
+			continue
+		}
+		r.Steps = append(r.Steps, st)
+	}
+	return r
+}
+
+// rejectSummary counts rejected rows per reason code, folding every
+// duplicate_of:<line> into duplicate_of.
+func (s *session) rejectSummary() []string {
+	counts := make(map[string]int)
+	var order []string
+	for _, r := range s.rejects {
+		reason := r.Code()
+		if counts[reason] == 0 {
+			order = append(order, reason)
+		}
+		counts[reason]++
+	}
+
+	lines := []string{fmt.Sprintf("%d rejected rows:", len(s.rejects))}
+	for _, reason := range order {
+		lines = append(lines, fmt.Sprintf("  %-15s %d", reason, counts[reason]))
+	}
+	return lines
+}
+
+// maxQuarantineText caps how much of a quarantined line is shown.
+const maxQuarantineText = 120
+
+// quarantineLines lists the CSV lines set aside on load, with their raw text.
+func (s *session) quarantineLines() []string {
+	q := s.dataset.Quarantined
+	if len(q) == 0 {
+		return []string{"No lines were quarantined."}
+	}
+	lines := []string{fmt.Sprintf("%d lines quarantined:", len(q))}
+	for _, l := range q {
+		raw := l.Raw
+		if r := []rune(raw); len(r) > maxQuarantineText {
+			raw = string(r[:maxQuarantineText]) + "..."
+		}
+		lines = append(lines, fmt.Sprintf("  line %d (%s): %s", l.Line, l.Problem, raw))
+	}
+	return lines
+}
+
+// maxClusterList caps how many clusters the clusters command prints.
+const maxClusterList = 50
+
+// clusterLines lists the rows the last dedup-fuzzy matched, one cluster at a
+// time with the row merge keeps first.
+func (s *session) clusterLines() []string {
+	if len(s.clusters) == 0 {
+		return []string{"No fuzzy matches to review; run dedup-fuzzy first."}
+	}
+	lines := []string{fmt.Sprintf("%d clusters (the first row of each is the one merge keeps):", len(s.clusters))}
+	for i, c := range s.clusters[:min(len(s.clusters), maxClusterList)] {
+		lines = append(lines, fmt.Sprintf("  #%d  score %.2f", i+1, c.Score))
+		for _, m := range c.Members {
+			var fields []string
+			for _, f := range []string{m.Name, m.Address, m.Zip, m.Email} {
+				if f != "" {
+					fields = append(fields, f)
+				}
+			}
+			lines = append(lines, fmt.Sprintf("      line %d: %s", m.Line, strings.Join(fields, ", ")))
+		}
+	}
+	if n := len(s.clusters) - maxClusterList; n > 0 {
+		lines = append(lines, fmt.Sprintf("  ... and %d more", n))
+	}
+	return lines
+}
+
+// parseVicidialArgs reads load-vicidial's arguments:
+//
+//	load-vicidial <list_id> [dry-run] [batch=500] [status=NEW] [phone-code=1] [conf=/etc/astguiclient.conf]
+//
+// confPath is the conf file to use when no conf= is given.
+func parseVicidialArgs(args []string, confPath string) (load.VicidialOptions, string, error) {
+	var opts load.VicidialOptions
+	if len(args) == 0 {
+		return opts, "", fmt.Errorf("usage: load-vicidial <list_id> [dry-run] [batch=N] [status=NEW] [phone-code=1] [conf=path]")
+	}
+	listID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return opts, "", fmt.Errorf("load-vicidial: bad list_id %q", args[0])
+	}
+	opts.ListID = listID
+
+	for _, a := range args[1:] {
+		key, val, _ := strings.Cut(a, "=")
+		switch strings.ToLower(key) {
+		case "dry-run", "dryrun":
+			opts.DryRun = true
+		case "batch":
+			if opts.BatchSize, err = strconv.Atoi(val); err != nil || opts.BatchSize <= 0 {
+				return opts, "", fmt.Errorf("load-vicidial: bad batch size %q", val)
+			}
+		case "status":
+			opts.Status = strings.ToUpper(val)
+		case "phone-code":
+			opts.PhoneCode = val
+		case "conf":
+			confPath = val
+		default:
+			return opts, "", fmt.Errorf("load-vicidial: unknown option %q", a)
+		}
+	}
+	return opts, confPath, nil
+}
+
+// existingPhones builds the phone set for dedup-existing from its arguments:
+//
+//	dedup-existing [system | list=1001,1002 | campaign=ID] [no-dnc] [dnc-file=path] [file=path] [conf=path]
+//
+// A scope queries the database named in astguiclient.conf (confPath unless
+// conf= is given); dnc-file and file add numbers from text files or earlier
+// exports.
+func existingPhones(ctx context.Context, args []string, confPath string) (*transform.PhoneSet, []string, error) {
+	var scope *load.ExistingScope
+	var dncFiles, leadFiles []string
+	skipDNC := false
+
+	for _, a := range args {
+		key, val, _ := strings.Cut(a, "=")
+		switch strings.ToLower(key) {
+		case load.ScopeSystem, load.ScopeList, load.ScopeCampaign:
+			sc, err := load.ParseExistingScope(a)
+			if err != nil {
+				return nil, nil, fmt.Errorf("dedup-existing: %w", err)
+			}
+			scope = &sc
+		case "no-dnc":
+			skipDNC = true
+		case "dnc-file":
+			dncFiles = append(dncFiles, val)
+		case "file":
+			leadFiles = append(leadFiles, val)
+		case "conf":
+			confPath = val
+		default:
+			// --steps splits list=1001,1002 at the comma, leaving bare ids
+			if id, err := strconv.ParseInt(a, 10, 64); err == nil && scope != nil && scope.Kind == load.ScopeList {
+				scope.Lists = append(scope.Lists, id)
+				continue
+			}
+			return nil, nil, fmt.Errorf("dedup-existing: unknown option %q", a)
+		}
+	}
+	if scope == nil && len(dncFiles) == 0 && len(leadFiles) == 0 {
+		return nil, nil, fmt.Errorf("usage: dedup-existing [system | list=<ids> | campaign=<id>] [no-dnc] [dnc-file=path] [file=path] [conf=path]")
+	}
+
+	set := transform.NewPhoneSet()
+	var lines []string
+
+	// DNC sources go in first so a number that is also loaded reports as DNC
+	for _, path := range dncFiles {
+		n, err := load.AddFilePhones(path, types.ReasonDNC, set)
+		if err != nil {
+			return nil, nil, err
+		}
+		lines = append(lines, fmt.Sprintf("Read %d DNC numbers from %s", n, path))
+	}
+	if scope != nil {
+		scope.SkipDNC = skipDNC
+		db, err := astguiclient.Open(ctx, confPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer db.Close()
+		n, err := load.AddDatabasePhones(ctx, db, *scope, set)
+		if err != nil {
+			return nil, nil, err
+		}
+		lines = append(lines, fmt.Sprintf("Read %d numbers from the database (%s scope)", n, scope.Kind))
+	}
+	for _, path := range leadFiles {
+		n, err := load.AddFilePhones(path, types.ReasonExistingLead, set)
+		if err != nil {
+			return nil, nil, err
+		}
+		lines = append(lines, fmt.Sprintf("Read %d numbers from %s", n, path))
+	}
+	return set, lines, nil
+}
+
+// columnIndex resolves a column given by index, header or role.
+func (s *session) columnIndex(column string) (int, error) {
+	if i, err := strconv.Atoi(column); err == nil {
+		if i < 0 || i >= len(s.dataset.Headers) {
+			return 0, fmt.Errorf("column index %d out of range", i)
+		}
+		return i, nil
+	}
+	for i, h := range s.dataset.Headers {
+		if strings.EqualFold(h, column) {
+			return i, nil
+		}
+	}
+	if role, err := extract.ParseRole(column); err == nil {
+		if i := s.dataset.Schema().Lookup(role); i >= 0 {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("no column %q (see 'columns')", column)
+}
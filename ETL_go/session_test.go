@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"etl_go/extract"
+	"etl_go/load"
+	"etl_go/transform"
+)
+
+func TestReportCountsWholeSession(t *testing.T) {
+	ds := &extract.DataSet{
+		Headers: []string{"First", "Last", "State", "Zip", "Phone"},
+		Rows: [][]string{
+			{"J4son", "Hall", "FL", "33610", "8135559999"},
+			{"Alice", "Smith", "FL", "33610", "(813) 555-9999"}, // duplicate phone
+			{"Bob", "Jones", "ZZ", "", "5125551111"},            // invalid state
+			{"", "", "TX", "73301", "5125552222"},               // no name
+			{"Eve", "Doe", "TX", "73301", ""},                   // no phone
+		},
+		Source: "test.csv",
+	}
+	s := newSession(ds)
+	for _, name := range []string{"clean-names", "normalize-phones", "dedup-phones", "validate-states", "final-validate"} {
+		if _, err := s.runStep(name, nil); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	r := s.report()
+	if r.TotalProcessed != 5 || r.FinalRowCount != 1 || r.TotalRemoved != 4 {
+		t.Errorf("processed/final/removed = %d/%d/%d, want 5/1/4", r.TotalProcessed, r.FinalRowCount, r.TotalRemoved)
+	}
+	if r.RemovedDuplicates != 1 || r.RemovedInvalidState != 1 || r.RemovedNoName != 1 || r.RemovedNoPhone != 1 {
+		t.Errorf("unexpected breakdown: %+v", r)
+	}
+	if r.NameStats.CleanedNumeric != 1 {
+		t.Errorf("expected 1 numeric name cleaned, got %d", r.NameStats.CleanedNumeric)
+	}
+	if len(r.Steps) != 5 || r.Steps[2].RowsIn != 5 || r.Steps[2].RowsOut != 4 {
+		t.Errorf("unexpected step stats: %+v", r.Steps)
+	}
+}
+
+func TestReportCountsDedupSteps(t *testing.T) {
+	ds := &extract.DataSet{
+		Headers: []string{"First", "Last", "Address", "City", "State", "Zip", "Phone"},
+		Rows: [][]string{
+			{"Ann", "Lee", "1 Elm St", "Tampa", "FL", "33610", "8135551111"},
+			{"Ann", "Lee", "1 Elm Street", "Tampa", "FL", "33610", "8135552222"}, // fuzzy duplicate
+			{"Bob", "Ray", "9 Oak Ave", "Austin", "TX", "73301", "5125553333"},   // already loaded
+		},
+		Source: "test.csv",
+	}
+	loaded := filepath.Join(t.TempDir(), "loaded.txt")
+	if err := os.WriteFile(loaded, []byte("5125553333\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newSession(ds)
+	for _, step := range [][]string{{"dedup-existing", "file=" + loaded}, {"dedup-fuzzy", "merge"}} {
+		if _, err := s.runStep(step[0], step[1:]); err != nil {
+			t.Fatalf("%s: %v", step[0], err)
+		}
+	}
+
+	want := []load.StepStats{{Name: "dedup-existing", RowsIn: 3, RowsOut: 2}, {Name: "dedup-fuzzy", RowsIn: 2, RowsOut: 1}}
+	if got := s.report().Steps; !reflect.DeepEqual(got, want) {
+		t.Errorf("step stats = %+v, want %+v", got, want)
+	}
+}
+
+func TestProfileStepSavesJSON(t *testing.T) {
+	ds := &extract.DataSet{
+		Headers: []string{"First", "Phone"},
+		Rows:    [][]string{{"Jo", "8135559999"}, {"", "555"}},
+		Source:  "test.csv",
+	}
+	s := newSession(ds)
+	path := filepath.Join(t.TempDir(), "profile.json")
+	lines, err := s.runStep("profile", []string{"phone", path})
+	if err != nil {
+		t.Fatalf("profile: %v", err)
+	}
+	if !strings.Contains(strings.Join(lines, "\n"), "normalize-phones would reject 1 (50.0%)") {
+		t.Errorf("expected the phone column's details, got %q", lines)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p transform.Profile
+	if err := json.Unmarshal(data, &p); err != nil {
+		t.Fatalf("bad JSON: %v", err)
+	}
+	if len(p.Columns) != 2 || p.Columns[0].FillRate != 0.5 || p.Columns[1].Role != extract.RolePhone {
+		t.Errorf("unexpected profile: %+v", p)
+	}
+	if s.dataset != ds || len(s.recipe().Steps) != 0 {
+		t.Error("profile should leave the dataset alone and stay out of recipes")
+	}
+}
+
+func TestCleanAllOnlyPrintsReport(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir) // the report goes next to test.csv
+
+	m := run(testModel(), "clean-all")
+	if got := files(t, dir); len(got) != 0 {
+		t.Errorf("clean-all saved %v", got)
+	}
+	if !m.steps[12].status {
+		t.Error("clean-all should still tick off the report step")
+	}
+
+	run(m, "write-report")
+	if got := files(t, dir); !reflect.DeepEqual(got, []string{"test_report.html", "test_report.json", "test_report.txt"}) {
+		t.Errorf("write-report saved %v", got)
+	}
+}
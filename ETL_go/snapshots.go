@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// maxSnapshots bounds the undo history; the oldest snapshot is dropped first.
+const maxSnapshots = 50
+
+// snapshot is the session state after one command. Snapshots hold the
+// dataset pointer rather than a copy: transforms never modify rows in place
+// and pass unchanged rows through as-is, so consecutive snapshots share
+// every row a step didn't touch.
+type snapshot struct {
+	label   string
+	session session
+}
+
+// clone copies the session's slices so later steps can't change a snapshot's
+// checklist, stats, rejects ledger or command history.
+func (s session) clone() session {
+	s.steps = slices.Clone(s.steps)
+	s.stepStats = slices.Clone(s.stepStats)
+	s.rejects = slices.Clone(s.rejects)
+	s.history = slices.Clone(s.history)
+	return s
+}
+
+// pushSnapshot records the current session after a command, discarding any
+// snapshots that could have been redone.
+func (m *model) pushSnapshot(label string) {
+	if len(m.snapshots) > 0 {
+		m.snapshots = m.snapshots[:m.current+1]
+	}
+	m.snapshots = append(m.snapshots, snapshot{label: label, session: m.session.clone()})
+	if len(m.snapshots) > maxSnapshots {
+		m.snapshots = m.snapshots[len(m.snapshots)-maxSnapshots:]
+	}
+	m.current = len(m.snapshots) - 1
+}
+
+// checkout restores snapshot n as the current session.
+func (m *model) checkout(n int) error {
+	if n < 0 || n >= len(m.snapshots) {
+		return fmt.Errorf("no snapshot %d (have 0-%d)", n, len(m.snapshots)-1)
+	}
+	m.current = n
+	m.session = m.snapshots[n].session.clone()
+	return nil
+}
+
+// historyLines lists the snapshots, marking the current one.
+func (m *model) historyLines() []string {
+	lines := []string{"Snapshots (use 'checkout <n>' to restore):"}
+	for i, snap := range m.snapshots {
+		marker := " "
+		if i == m.current {
+			marker = "*"
+		}
+		lines = append(lines, fmt.Sprintf("%s %2d  %-30s %d rows, %d columns",
+			marker, i, snap.label, len(snap.session.dataset.Rows), len(snap.session.dataset.Headers)))
+	}
+	return lines
+}
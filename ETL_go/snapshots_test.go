@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"etl_go/extract"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func testModel() model {
+	ds := &extract.DataSet{
+		Headers: []string{"First", "Last", "Address", "State", "Zip", "Phone", "Email"},
+		Rows: [][]string{
+			{"J4son", "Hall", "123 Main St", "FL", "33610", "8135559999", ""},
+			{"Alice", "Smith", "77## Weird Blvd!", "TX", "73301", "5125558888", ""},
+		},
+		Source: "test.csv",
+	}
+	m := model{session: newSession(ds)}
+	m.pushSnapshot("load test.csv")
+	return m
+}
+
+// run runs cmd, waiting for it to finish if it went to the background.
+func run(m model, cmd string) model {
+	next, c := m.processCommand(cmd)
+	for c != nil {
+		next, c = next.(model).Update(c())
+	}
+	return next.(model)
+}
+
+func TestUndoRedoRestoresDatasetAndChecklist(t *testing.T) {
+	m := testModel()
+	m = run(m, "clean-names")
+	if m.dataset.Rows[0][0] != "" || !m.steps[3].status {
+		t.Fatalf("clean-names did not run: %q, %v", m.dataset.Rows[0][0], m.steps[3].status)
+	}
+
+	m = run(m, "undo")
+	if m.dataset.Rows[0][0] != "J4son" {
+		t.Errorf("undo: expected J4son back, got %q", m.dataset.Rows[0][0])
+	}
+	if m.steps[3].status {
+		t.Error("undo: Clean Names should be unchecked")
+	}
+
+	m = run(m, "redo")
+	if m.dataset.Rows[0][0] != "" || !m.steps[3].status {
+		t.Errorf("redo: expected clean-names result back")
+	}
+
+	m = run(m, "checkout 0")
+	m = run(m, "drop 6")
+	if len(m.snapshots) != 2 {
+		t.Errorf("a new command after checkout should discard redo snapshots, have %d", len(m.snapshots))
+	}
+}
+
+func TestSnapshotsShareUnchangedRows(t *testing.T) {
+	m := testModel()
+	m = run(m, "clean-address")
+
+	before := m.snapshots[0].session.dataset
+	after := m.snapshots[1].session.dataset
+	if &before.Rows[0][0] != &after.Rows[0][0] {
+		t.Error("row 0 was not changed by clean-address and should be shared")
+	}
+	if &before.Rows[1][0] == &after.Rows[1][0] {
+		t.Error("row 1 was changed by clean-address and must not be shared")
+	}
+}
+
+func TestTypingQDoesNotQuit(t *testing.T) {
+	m := testModel()
+	m.focused = "input"
+	for _, r := range "quarantine" {
+		next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		if cmd != nil {
+			t.Fatalf("typing %q returned a command", r)
+		}
+		m = next.(model)
+	}
+	if m.input != "quarantine" {
+		t.Fatalf("input = %q", m.input)
+	}
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(model)
+	if !strings.Contains(strings.Join(m.outputLines, "\n"), "> quarantine") {
+		t.Errorf("quarantine did not run: %q", m.outputLines)
+	}
+
+	// With the output pane focused, q still quits
+	m.focused = "output"
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}); cmd == nil {
+		t.Error("q in the output pane should quit")
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"etl_go/extract"
+	"etl_go/load"
+	"etl_go/transform"
+	"etl_go/types"
+
+	"leadkit/geodata"
+)
+
+// maxStreamQuarantine caps how many quarantined lines runStream prints.
+const maxStreamQuarantine = 10
+
+// runStream runs the clean-all pipeline over a CSV file one row at a time,
+// for files too large to load into the TUI.
+//
+//	etl_go stream [--drop 9,10,12] [--delimiter tab] [--encoding windows-1252] [--ragged pad] <input.csv> [output.csv]
+func runStream(args []string) error {
+	fs := flag.NewFlagSet("stream", flag.ContinueOnError)
+	drop := fs.String("drop", "", "comma-separated column indexes to drop before cleaning")
+	delimiter := fs.String("delimiter", "auto", "CSV field separator: auto, \",\", tab, \"|\" or \";\"")
+	encoding := fs.String("encoding", "auto", "CSV text encoding: auto, utf-8, windows-1252, latin-1, utf-16le or utf-16be")
+	ragged := fs.String("ragged", "pad", "rows with too few or too many fields: pad, truncate or reject")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 {
+		return fmt.Errorf("usage: etl_go stream [--drop 9,10,12] [--delimiter tab] [--encoding windows-1252] [--ragged pad] <input.csv> [output.csv]")
+	}
+
+	csvSrc, err := extract.OpenCSV(fs.Arg(0), extract.ReadOptions{
+		Delimiter: *delimiter,
+		Encoding:  *encoding,
+		Ragged:    extract.RaggedPolicy(*ragged),
+	})
+	if err != nil {
+		return err
+	}
+	defer csvSrc.Close()
+	fmt.Printf("Reading %s (%s)\n", csvSrc.Name(), csvSrc.Format())
+
+	var src extract.RowSource = csvSrc
+	if *drop != "" {
+		indexes, err := parseIndexList(*drop)
+		if err != nil {
+			return err
+		}
+		src = transform.DropColumnsSource(src, indexes)
+	}
+
+	var nameStats types.NameStats
+	var geoStats types.GeoStats
+	fns, err := streamSteps(extract.NewSchema(src.Headers(), nil), &nameStats, &geoStats)
+	if err != nil {
+		return err
+	}
+
+	outFile := fs.Arg(1)
+	if outFile == "" {
+		outFile = load.CleanedFileName(src.Name())
+	}
+	sink, err := load.CreateCSV(outFile, src.Headers())
+	if err != nil {
+		return err
+	}
+
+	var rejects []types.Reject
+	onReject := func(r types.Reject) { rejects = append(rejects, r) }
+
+	stats, err := transform.Stream(src, sink, onReject, fns...)
+	if closeErr := sink.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	// Rows the ragged-row policy dropped never reached the pipeline
+	rejects = append(slices.Clone(csvSrc.Rejects()), rejects...)
+	read := stats.Read + len(csvSrc.Rejects())
+	for _, line := range csvSrc.Shape().Lines() {
+		fmt.Println(line)
+	}
+
+	fmt.Printf("%d rows read, %d removed, %d written to %s\n", read, len(rejects), stats.Written, outFile)
+	if len(rejects) > 0 {
+		rejectsFile := load.OutputFileName(src.Name(), "rejects", ".csv")
+		if err := load.WriteRejects(rejects, rejectsFile); err != nil {
+			return err
+		}
+		fmt.Printf("%d rejected rows written to %s\n", len(rejects), rejectsFile)
+	}
+	quarantined := csvSrc.Quarantined()
+	for i, q := range quarantined {
+		if i == maxStreamQuarantine {
+			fmt.Printf("  ... and %d more\n", len(quarantined)-i)
+			break
+		}
+		fmt.Printf("  quarantined line %d (%s): %s\n", q.Line, q.Problem, q.Raw)
+	}
+	report := load.ReportSummary{
+		Source:           src.Name(),
+		TotalProcessed:   read,
+		QuarantinedLines: len(quarantined),
+		NameStats:        nameStats,
+		GeoStats:         geoStats,
+		AreaCodeVersion:  geodata.AreaCodes().Version,
+		ZipVersion:       geodata.Zips().Version,
+		FinalRowCount:    stats.Written,
+	}
+	report.AddRejects(rejects)
+	for _, line := range load.WriteReport(report) {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// streamSteps builds the row-level form of the clean-all pipeline for schema.
+func streamSteps(schema *extract.Schema, nameStats *types.NameStats, geoStats *types.GeoStats) ([]transform.RowFunc, error) {
+	builders := []func(*extract.Schema) (transform.RowFunc, error){
+		transform.AddressCleaner,
+		func(s *extract.Schema) (transform.RowFunc, error) { return transform.NameCleaner(s, nameStats) },
+		transform.EmailCleaner,
+		transform.StateCleaner,
+		transform.PhoneNormalizer,
+		transform.PhoneDeduper,
+		func(s *extract.Schema) (transform.RowFunc, error) {
+			return transform.GeoPopulator(s, transform.GeoOptions{}, geoStats)
+		},
+		transform.StateValidator,
+		load.FinalValidator,
+	}
+
+	fns := make([]transform.RowFunc, 0, len(builders))
+	for _, build := range builders {
+		fn, err := build(schema)
+		if err != nil {
+			return nil, err
+		}
+		fns = append(fns, fn)
+	}
+	return fns, nil
+}
+
+// parseIndexList parses "9,10,12" into []int{9, 10, 12}.
+func parseIndexList(s string) ([]int, error) {
+	var indexes []int
+	for _, part := range strings.Split(s, ",") {
+		i, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid column index: %s", part)
+		}
+		indexes = append(indexes, i)
+	}
+	return indexes, nil
+}
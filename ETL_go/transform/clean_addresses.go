@@ -7,37 +7,39 @@ import (
 	"etl_go/extract"
 )
 
+// addressJunk matches everything except A–Z, 0–9, spaces, # / - .
+var addressJunk = regexp.MustCompile(`[^A-Za-z0-9\s#\/\-\.]`)
+
 // CleanAddresses removes unwanted special characters from the address1 column.
 // It keeps alphanumeric characters, spaces, and these symbols: # / - .
 // Commas are removed to prevent CSV misalignment.
-func CleanAddresses(ds *extract.DataSet) *extract.DataSet {
+func CleanAddresses(ds *extract.DataSet) (*extract.DataSet, error) {
 	if ds == nil {
 		fmt.Println("No dataset loaded.")
-		return ds
+		return ds, nil
 	}
 
-	// Address1 column index (0-based)
-	const address1Idx = 4
-
-	// Regex: remove everything except A–Z, 0–9, spaces, # / - .
-	re := regexp.MustCompile(`[^A-Za-z0-9\s#\/\-\.]`)
+	fn, err := AddressCleaner(ds.Schema())
+	if err != nil {
+		return ds, err
+	}
+	cleaned, _ := ApplyParallel(ds, fn)
+	return cleaned, nil
+}
 
-	newRows := make([][]string, len(ds.Rows))
-	for i, row := range ds.Rows {
-		newRow := make([]string, len(row))
-		copy(newRow, row)
+// AddressCleaner returns the per-row form of CleanAddresses.
+func AddressCleaner(schema *extract.Schema) (RowFunc, error) {
+	cols, err := schema.Require(extract.RoleAddress1)
+	if err != nil {
+		return nil, fmt.Errorf("clean-address: %w", err)
+	}
+	address1Idx := cols[0]
 
+	return func(line int, row []string) ([]string, string) {
+		e := rowEdit{row: row}
 		if address1Idx < len(row) && row[address1Idx] != "" {
-			cleaned := re.ReplaceAllString(row[address1Idx], "")
-			newRow[address1Idx] = cleaned
+			e.set(address1Idx, addressJunk.ReplaceAllString(row[address1Idx], ""))
 		}
-
-		newRows[i] = newRow
-	}
-
-	return &extract.DataSet{
-		Headers: ds.Headers,
-		Rows:    newRows,
-		Source:  ds.Source,
-	}
+		return e.row, ""
+	}, nil
 }
@@ -7,37 +7,40 @@ import (
 	"etl_go/extract"
 )
 
+// digitsOnly matches strings that consist only of digits.
+var digitsOnly = regexp.MustCompile(`^[0-9]+$`)
+
 // CleanEmails replaces numeric-only email values with an empty string.
 // If the value in the email column is a number (e.g. "12345"), it will be cleared.
-func CleanEmails(ds *extract.DataSet) *extract.DataSet {
+func CleanEmails(ds *extract.DataSet) (*extract.DataSet, error) {
 	if ds == nil {
 		fmt.Println("No dataset loaded.")
-		return ds
+		return ds, nil
 	}
 
-	// Email column index (0-based)
-	const emailIdx = 11
-
-	// Regex: matches strings that consist only of digits
-	re := regexp.MustCompile(`^[0-9]+$`)
+	fn, err := EmailCleaner(ds.Schema())
+	if err != nil {
+		return ds, err
+	}
+	cleaned, _ := ApplyParallel(ds, fn)
+	return cleaned, nil
+}
 
-	newRows := make([][]string, len(ds.Rows))
-	for i, row := range ds.Rows {
-		newRow := make([]string, len(row))
-		copy(newRow, row)
+// EmailCleaner returns the per-row form of CleanEmails.
+func EmailCleaner(schema *extract.Schema) (RowFunc, error) {
+	cols, err := schema.Require(extract.RoleEmail)
+	if err != nil {
+		return nil, fmt.Errorf("clean-email: %w", err)
+	}
+	emailIdx := cols[0]
 
+	return func(line int, row []string) ([]string, string) {
+		e := rowEdit{row: row}
 		if emailIdx < len(row) && row[emailIdx] != "" {
-			if re.MatchString(row[emailIdx]) {
-				newRow[emailIdx] = ""
+			if digitsOnly.MatchString(row[emailIdx]) {
+				e.set(emailIdx, "")
 			}
 		}
-
-		newRows[i] = newRow
-	}
-
-	return &extract.DataSet{
-		Headers: ds.Headers,
-		Rows:    newRows,
-		Source:  ds.Source,
-	}
+		return e.row, ""
+	}, nil
 }
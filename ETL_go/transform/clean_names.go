@@ -6,66 +6,59 @@ import (
 	"strings"
 
 	"etl_go/extract"
+	"etl_go/types"
 )
 
+// nameJunk matches everything except letters, spaces, hyphens and apostrophes.
+var nameJunk = regexp.MustCompile(`[^A-Za-z\s\-']`)
+
 // CleanNames removes numeric values and special characters from first, middle, and last name fields.
-func CleanNames(ds *extract.DataSet) *extract.DataSet {
+// The middle name column is optional; first and last name columns are required.
+func CleanNames(ds *extract.DataSet) (*extract.DataSet, types.NameStats, error) {
 	if ds == nil {
 		fmt.Println("No dataset loaded.")
-		return ds
+		return ds, types.NameStats{}, nil
 	}
 
-	// Name column indices (0-based) - adjust these based on your actual column positions
-	const firstNameIdx = 1
-	const middleNameIdx = 2
-	const lastNameIdx = 3
-
-	// Regex: keep only letters, spaces, hyphens, and apostrophes for names
-	re := regexp.MustCompile(`[^A-Za-z\s\-']`)
-
-	stats := struct {
-		cleanedNumeric int
-		cleanedSpecial int
-	}{}
-
-	newRows := make([][]string, len(ds.Rows))
-	for i, row := range ds.Rows {
-		newRow := make([]string, len(row))
-		copy(newRow, row)
-
-		// Process first name
-		if firstNameIdx < len(row) && row[firstNameIdx] != "" {
-			cleaned := cleanNameField(row[firstNameIdx], re, &stats)
-			newRow[firstNameIdx] = cleaned
-		}
-
-		// Process middle name
-		if middleNameIdx < len(row) && row[middleNameIdx] != "" {
-			cleaned := cleanNameField(row[middleNameIdx], re, &stats)
-			newRow[middleNameIdx] = cleaned
-		}
-
-		// Process last name
-		if lastNameIdx < len(row) && row[lastNameIdx] != "" {
-			cleaned := cleanNameField(row[lastNameIdx], re, &stats)
-			newRow[lastNameIdx] = cleaned
-		}
-
-		newRows[i] = newRow
+	schema := ds.Schema()
+	cleaned, _, perWorker, err := applyParallelStats(ds, func(stats *types.NameStats) (RowFunc, error) {
+		return NameCleaner(schema, stats)
+	})
+	if err != nil {
+		return ds, types.NameStats{}, err
+	}
+	var stats types.NameStats
+	for _, w := range perWorker {
+		stats.Add(w)
 	}
+	return cleaned, stats, nil
+}
 
-	return &extract.DataSet{
-		Headers: ds.Headers,
-		Rows:    newRows,
-		Source:  ds.Source,
+// NameCleaner returns the per-row form of CleanNames. Counters are added
+// to stats as rows go through it.
+func NameCleaner(schema *extract.Schema, stats *types.NameStats) (RowFunc, error) {
+	cols, err := schema.Require(extract.RoleFirstName, extract.RoleLastName)
+	if err != nil {
+		return nil, fmt.Errorf("clean-names: %w", err)
+	}
+	nameCols := []int{cols[0], cols[1]}
+	if middleNameIdx := schema.Lookup(extract.RoleMiddleName); middleNameIdx >= 0 {
+		nameCols = append(nameCols, middleNameIdx)
 	}
+
+	return func(line int, row []string) ([]string, string) {
+		e := rowEdit{row: row}
+		for _, idx := range nameCols {
+			if idx < len(row) && row[idx] != "" {
+				e.set(idx, cleanNameField(row[idx], stats))
+			}
+		}
+		return e.row, ""
+	}, nil
 }
 
 // cleanNameField processes a single name field
-func cleanNameField(value string, re *regexp.Regexp, stats *struct {
-	cleanedNumeric int
-	cleanedSpecial int
-}) string {
+func cleanNameField(value string, stats *types.NameStats) string {
 	original := strings.TrimSpace(value)
 	if original == "" {
 		return ""
@@ -73,17 +66,17 @@ func cleanNameField(value string, re *regexp.Regexp, stats *struct {
 
 	// Check if the value contains ANY numeric characters
 	if containsAnyNumbers(original) {
-		stats.cleanedNumeric++
+		stats.CleanedNumeric++
 		return "" // Remove the entire value if it contains any numbers
 	}
 
 	// Remove special characters (keep only letters, spaces, hyphens, apostrophes)
-	cleaned := re.ReplaceAllString(original, "")
+	cleaned := nameJunk.ReplaceAllString(original, "")
 	cleaned = strings.TrimSpace(cleaned)
 
 	// Track if we removed special characters
 	if cleaned != original {
-		stats.cleanedSpecial++
+		stats.CleanedSpecial++
 	}
 
 	return cleaned
@@ -1,43 +1,49 @@
 package transform
 
 import (
+	"fmt"
 	"strings"
 	"unicode"
 
 	"etl_go/extract"
 )
 
-// CleanStates ensures that the state field (row[6]) contains only valid 2-letter alphabetic codes.
+// CleanStates ensures that the state column contains only valid 2-letter alphabetic codes.
 // If not, it blanks out the state value but does NOT drop the row.
-func CleanStates(ds *extract.DataSet) *extract.DataSet {
+func CleanStates(ds *extract.DataSet) (*extract.DataSet, error) {
 	if ds == nil {
-		return ds
+		return ds, nil
 	}
 
-	newRows := make([][]string, len(ds.Rows))
+	fn, err := StateCleaner(ds.Schema())
+	if err != nil {
+		return ds, err
+	}
+	cleaned, _ := ApplyParallel(ds, fn)
+	return cleaned, nil
+}
 
-	for i, row := range ds.Rows {
-		newRow := make([]string, len(row))
-		copy(newRow, row)
+// StateCleaner returns the per-row form of CleanStates.
+func StateCleaner(schema *extract.Schema) (RowFunc, error) {
+	cols, err := schema.Require(extract.RoleState)
+	if err != nil {
+		return nil, fmt.Errorf("clean-states: %w", err)
+	}
+	stateIdx := cols[0]
 
-		if len(newRow) > 6 {
-			state := strings.ToUpper(strings.TrimSpace(newRow[6]))
+	return func(line int, row []string) ([]string, string) {
+		e := rowEdit{row: row}
+		if stateIdx < len(row) {
+			state := strings.ToUpper(strings.TrimSpace(row[stateIdx]))
 
 			if !isTwoLetterAlpha(state) {
-				newRow[6] = ""
+				e.set(stateIdx, "")
 			} else {
-				newRow[6] = state
+				e.set(stateIdx, state)
 			}
 		}
-
-		newRows[i] = newRow
-	}
-
-	return &extract.DataSet{
-		Headers: ds.Headers,
-		Rows:    newRows,
-		Source:  ds.Source,
-	}
+		return e.row, ""
+	}, nil
 }
 
 // Helper: returns true only if the string is exactly two letters A–Z
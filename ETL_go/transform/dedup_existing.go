@@ -0,0 +1,80 @@
+package transform
+
+import (
+	"fmt"
+
+	"etl_go/extract"
+	"etl_go/types"
+
+	"leadkit/phone"
+)
+
+// PhoneSet holds normalized phone numbers from outside the dataset - leads
+// already loaded, DNC entries, earlier exports - each with the reject reason
+// a matching row gets.
+type PhoneSet struct {
+	reasons map[string]string
+}
+
+// NewPhoneSet returns an empty set.
+func NewPhoneSet() *PhoneSet {
+	return &PhoneSet{reasons: make(map[string]string)}
+}
+
+// Add records a phone number with the reason a match is rejected for, such as
+// "dnc:system" or "existing_lead:list_1001". A number already in the set
+// keeps its first reason, so add DNC sources before lead sources. Blank
+// numbers are ignored.
+func (p *PhoneSet) Add(raw, reason string) {
+	num := phone.Normalize(raw)
+	if num == "" {
+		return
+	}
+	if _, ok := p.reasons[num]; !ok {
+		p.reasons[num] = reason
+	}
+}
+
+// Lookup returns the reject reason for a phone number, if it is in the set.
+func (p *PhoneSet) Lookup(raw string) (string, bool) {
+	reason, ok := p.reasons[phone.Normalize(raw)]
+	return reason, ok
+}
+
+// Len returns the number of distinct phone numbers in the set.
+func (p *PhoneSet) Len() int {
+	return len(p.reasons)
+}
+
+// DedupExisting removes rows whose phone number is in set.
+func DedupExisting(ds *extract.DataSet, set *PhoneSet) (*extract.DataSet, []types.Reject, error) {
+	if ds == nil {
+		return nil, nil, fmt.Errorf("no dataset loaded")
+	}
+	fn, err := ExistingDeduper(ds.Schema(), set)
+	if err != nil {
+		return nil, nil, err
+	}
+	cleaned, dropped := ApplyRows(ds, fn)
+	return cleaned, dropped, nil
+}
+
+// ExistingDeduper returns the per-row form of DedupExisting. Rows without a
+// phone are kept.
+func ExistingDeduper(schema *extract.Schema, set *PhoneSet) (RowFunc, error) {
+	cols, err := schema.Require(extract.RolePhone)
+	if err != nil {
+		return nil, fmt.Errorf("dedup-existing: %w", err)
+	}
+	phoneIdx := cols[0]
+
+	return func(line int, row []string) ([]string, string) {
+		if phoneIdx >= len(row) {
+			return row, ""
+		}
+		if reason, ok := set.Lookup(row[phoneIdx]); ok {
+			return row, reason
+		}
+		return row, ""
+	}, nil
+}
@@ -0,0 +1,346 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"etl_go/extract"
+	"etl_go/types"
+)
+
+// Blocking keys for FuzzyOptions.Block. Only rows sharing a key are compared.
+const (
+	BlockZip  = "zip"  // first five digits of the ZIP
+	BlockLast = "last" // first three letters of the last name
+)
+
+// DefaultFuzzyThreshold is the score a pair needs to count as one person.
+const DefaultFuzzyThreshold = 0.88
+
+// maxFuzzyBlock caps the rows compared pairwise in one block; bigger blocks
+// are skipped and counted rather than scored.
+const maxFuzzyBlock = 2000
+
+// Weights of each field in a pair's score. Fields missing from either row
+// are left out and the rest are scaled up to compensate.
+const (
+	weightLast    = 0.35
+	weightFirst   = 0.25
+	weightAddress = 0.25
+	weightEmail   = 0.15
+)
+
+// FuzzyOptions controls DedupFuzzy.
+type FuzzyOptions struct {
+	Block     string  // BlockZip or BlockLast
+	Threshold float64 // pair score needed to match, 0-1
+	Merge     bool    // drop all but the first row of each cluster; otherwise only flag
+}
+
+// ParseFuzzyOptions reads dedup-fuzzy's arguments:
+//
+//	[block=zip|last] [threshold=0.88] [merge | flag]
+func ParseFuzzyOptions(args []string) (FuzzyOptions, error) {
+	opts := FuzzyOptions{Block: BlockZip, Threshold: DefaultFuzzyThreshold}
+	for _, a := range args {
+		key, val, _ := strings.Cut(a, "=")
+		switch strings.ToLower(key) {
+		case "block":
+			switch val = strings.ToLower(val); val {
+			case BlockZip, BlockLast:
+				opts.Block = val
+			default:
+				return opts, fmt.Errorf("dedup-fuzzy: unknown block %q (expected zip or last)", val)
+			}
+		case "threshold":
+			t, err := strconv.ParseFloat(val, 64)
+			if err != nil || t <= 0 || t > 1 {
+				return opts, fmt.Errorf("dedup-fuzzy: bad threshold %q (expected a number in (0, 1])", val)
+			}
+			opts.Threshold = t
+		case "merge":
+			opts.Merge = true
+		case "flag":
+			opts.Merge = false
+		default:
+			return opts, fmt.Errorf("dedup-fuzzy: unknown option %q", a)
+		}
+	}
+	return opts, nil
+}
+
+// FuzzyMember is one row of a FuzzyCluster as it was when matched.
+type FuzzyMember struct {
+	Line    int
+	Name    string
+	Address string
+	Zip     string
+	Email   string
+}
+
+// FuzzyCluster is a group of rows judged to be the same person. The first
+// member is the one kept when merging.
+type FuzzyCluster struct {
+	Members []FuzzyMember
+	Score   float64 // lowest pair score that joined the cluster
+}
+
+// FuzzyResult holds the outcome of DedupFuzzy.
+type FuzzyResult struct {
+	Cleaned       *extract.DataSet
+	Dropped       []types.Reject // each marked fuzzy_duplicate_of:<line of the row kept>
+	Clusters      []FuzzyCluster
+	Pairs         int // candidate pairs scored
+	SkippedBlocks int // blocks over maxFuzzyBlock rows
+}
+
+// fuzzyRecord is a row's fields normalized for comparison.
+type fuzzyRecord struct {
+	first, last, address, email, zip string
+}
+
+// DedupFuzzy finds rows that are probably the same person under different
+// phone numbers or with a misspelled name. Rows are grouped by opts.Block
+// and every pair in a group is scored on last and first name (Jaro-Winkler,
+// with nicknames such as Bob/Robert counting as equal), address and email.
+// A pair needs matching names plus an address or email to compare, so two
+// strangers who share a name and a ZIP don't match on the name alone.
+// Pairs at or above opts.Threshold are joined into clusters. With
+// opts.Merge, the first row of each cluster is kept, its blank fields filled
+// from the others, and the rest are dropped; otherwise the dataset is
+// returned unchanged and the clusters are only listed.
+//
+// Unlike the other transforms it needs every row at once, so it has no
+// per-row form for streaming. It stops with ctx's error if ctx is
+// cancelled while pairs are being scored.
+func DedupFuzzy(ctx context.Context, ds *extract.DataSet, opts FuzzyOptions) (*FuzzyResult, error) {
+	if ds == nil {
+		return nil, fmt.Errorf("no dataset loaded")
+	}
+	schema := ds.Schema()
+	roles := []extract.Role{extract.RoleFirstName, extract.RoleLastName}
+	if opts.Block != BlockLast {
+		roles = append(roles, extract.RoleZip)
+	}
+	if _, err := schema.Require(roles...); err != nil {
+		return nil, fmt.Errorf("dedup-fuzzy: %w", err)
+	}
+	cols := fuzzyColumns{
+		first:   schema.Lookup(extract.RoleFirstName),
+		last:    schema.Lookup(extract.RoleLastName),
+		address: schema.Lookup(extract.RoleAddress1),
+		email:   schema.Lookup(extract.RoleEmail),
+		zip:     schema.Lookup(extract.RoleZip),
+	}
+
+	records := make([]fuzzyRecord, len(ds.Rows))
+	blocks := make(map[string][]int)
+	var keys []string
+	for i, row := range ds.Rows {
+		records[i] = cols.record(row)
+		key := records[i].blockKey(opts.Block)
+		if key == "" {
+			continue
+		}
+		if _, ok := blocks[key]; !ok {
+			keys = append(keys, key)
+		}
+		blocks[key] = append(blocks[key], i)
+	}
+
+	result := &FuzzyResult{Cleaned: ds}
+	parent := make([]int, len(ds.Rows)) // union-find over row indexes
+	for i := range parent {
+		parent[i] = i
+	}
+	lowest := make(map[int]float64) // cluster root -> lowest joining score
+
+	for _, key := range keys {
+		block := blocks[key]
+		if len(block) > maxFuzzyBlock {
+			result.SkippedBlocks++
+			continue
+		}
+		for x, i := range block {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			for _, j := range block[x+1:] {
+				score, ok := records[i].score(records[j])
+				if !ok {
+					continue
+				}
+				result.Pairs++
+				if score < opts.Threshold {
+					continue
+				}
+				ri, rj := findRoot(parent, i), findRoot(parent, j)
+				low := score
+				for _, r := range []int{ri, rj} {
+					if s, ok := lowest[r]; ok {
+						low = min(low, s)
+					}
+				}
+				// The lower index stays the root, so it is the row kept
+				root, other := min(ri, rj), max(ri, rj)
+				parent[other] = root
+				delete(lowest, other)
+				lowest[root] = low
+			}
+		}
+	}
+
+	members := make(map[int][]int) // root -> row indexes, in dataset order
+	var roots []int
+	for i := range ds.Rows {
+		r := findRoot(parent, i)
+		if r == i && lowest[r] == 0 {
+			continue // not matched with anything
+		}
+		if _, ok := members[r]; !ok {
+			roots = append(roots, r)
+		}
+		members[r] = append(members[r], i)
+	}
+	slices.Sort(roots)
+
+	dropped := make(map[int]int) // row index -> line of the row kept
+	merged := make(map[int][]string)
+	for _, r := range roots {
+		cluster := FuzzyCluster{Score: lowest[r]}
+		for _, i := range members[r] {
+			cluster.Members = append(cluster.Members, cols.member(ds.Line(i), ds.Rows[i]))
+			if i != r {
+				dropped[i] = ds.Line(r)
+			}
+		}
+		result.Clusters = append(result.Clusters, cluster)
+		if opts.Merge {
+			merged[r] = mergeRows(ds.Rows, members[r])
+		}
+	}
+	if !opts.Merge {
+		return result, nil
+	}
+
+	kept := make([][]string, 0, len(ds.Rows)-len(dropped))
+	lines := make([]int, 0, len(ds.Rows)-len(dropped))
+	for i, row := range ds.Rows {
+		if keptLine, ok := dropped[i]; ok {
+			result.Dropped = append(result.Dropped, types.Reject{
+				Line:    ds.Line(i),
+				Reason:  fmt.Sprintf("%s:%d", types.ReasonFuzzyDuplicateOf, keptLine),
+				Row:     row,
+				Headers: ds.Headers,
+			})
+			continue
+		}
+		if m, ok := merged[i]; ok {
+			row = m
+		}
+		kept = append(kept, row)
+		lines = append(lines, ds.Line(i))
+	}
+	result.Cleaned = ds.WithRows(kept, lines)
+	return result, nil
+}
+
+// findRoot returns the root of i, flattening the path as it goes.
+func findRoot(parent []int, i int) int {
+	for parent[i] != i {
+		parent[i] = parent[parent[i]]
+		i = parent[i]
+	}
+	return i
+}
+
+// mergeRows returns a copy of the first row in idx with each blank field
+// filled from the first later row that has it.
+func mergeRows(rows [][]string, idx []int) []string {
+	out := slices.Clone(rows[idx[0]])
+	for _, i := range idx[1:] {
+		for c, v := range rows[i] {
+			if c < len(out) && strings.TrimSpace(out[c]) == "" {
+				out[c] = v
+			}
+		}
+	}
+	return out
+}
+
+// fuzzyColumns holds the column positions DedupFuzzy reads; address, email
+// and zip are -1 when the dataset doesn't have them.
+type fuzzyColumns struct {
+	first, last, address, email, zip int
+}
+
+// field returns row[i] trimmed, or "" when the column is missing.
+func field(row []string, i int) string {
+	if i < 0 || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+func (c fuzzyColumns) record(row []string) fuzzyRecord {
+	zip := field(row, c.zip)
+	if len(zip) > 5 {
+		zip = zip[:5]
+	}
+	return fuzzyRecord{
+		first:   normalizeName(field(row, c.first)),
+		last:    normalizeName(field(row, c.last)),
+		address: normalizeAddress(field(row, c.address)),
+		email:   strings.ToLower(field(row, c.email)),
+		zip:     zip,
+	}
+}
+
+func (c fuzzyColumns) member(line int, row []string) FuzzyMember {
+	return FuzzyMember{
+		Line:    line,
+		Name:    strings.TrimSpace(field(row, c.first) + " " + field(row, c.last)),
+		Address: field(row, c.address),
+		Zip:     field(row, c.zip),
+		Email:   field(row, c.email),
+	}
+}
+
+// blockKey returns the group r is compared within, or "" to leave it out.
+func (r fuzzyRecord) blockKey(block string) string {
+	if block == BlockLast {
+		last := []rune(r.last)
+		return string(last[:min(3, len(last))])
+	}
+	return r.zip
+}
+
+// score rates how likely r and o are the same person. ok is false when the
+// pair lacks the fields to judge: both names, and an address or email.
+func (r fuzzyRecord) score(o fuzzyRecord) (float64, bool) {
+	if r.first == "" || o.first == "" || r.last == "" || o.last == "" {
+		return 0, false
+	}
+	hasAddress := r.address != "" && o.address != ""
+	hasEmail := r.email != "" && o.email != ""
+	if !hasAddress && !hasEmail {
+		return 0, false
+	}
+
+	total := weightLast*jaroWinkler(r.last, o.last) + weightFirst*firstNameSimilarity(r.first, o.first)
+	weight := weightLast + weightFirst
+	if hasAddress {
+		total += weightAddress * addressSimilarity(r.address, o.address)
+		weight += weightAddress
+	}
+	if hasEmail {
+		if r.email == o.email {
+			total += weightEmail
+		}
+		weight += weightEmail
+	}
+	return total / weight, true
+}
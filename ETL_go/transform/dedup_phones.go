@@ -2,90 +2,71 @@ package transform
 
 import (
 	"fmt"
-	"regexp"
-	"strings"
 
 	"etl_go/extract"
+	"etl_go/types"
+
+	"leadkit/dedup"
 )
 
 // DedupResult holds the results of phone deduplication
 type DedupResult struct {
 	Cleaned    *extract.DataSet
+	Dropped    []types.Reject // each marked duplicate_of:<line of the row kept>
 	Duplicates int
 }
 
 // DedupPhones removes duplicate rows based on normalized phone numbers
-func DedupPhones(ds *extract.DataSet) *DedupResult {
+func DedupPhones(ds *extract.DataSet) (*DedupResult, error) {
 	if ds == nil {
 		fmt.Println("No dataset loaded.")
-		return &DedupResult{Cleaned: ds, Duplicates: 0}
+		return &DedupResult{Cleaned: ds, Duplicates: 0}, nil
 	}
 
-	// Find phone column index (adjust based on your column position)
-	const phoneColIdx = 8 // Assuming phone is at index 8, adjust as needed
-
-	// If we can't find the phone column in headers, try to locate it
-	actualPhoneIdx := phoneColIdx
-	if len(ds.Headers) > phoneColIdx && !strings.Contains(strings.ToLower(ds.Headers[phoneColIdx]), "phone") {
-		// Try to find phone column by name
-		for i, header := range ds.Headers {
-			if strings.Contains(strings.ToLower(header), "phone") {
-				actualPhoneIdx = i
-				break
-			}
-		}
+	fn, err := PhoneDeduper(ds.Schema())
+	if err != nil {
+		return nil, err
 	}
+	cleaned, dropped := ApplyRows(ds, fn)
 
-	seen := make(map[string]bool)
-	var uniqueRows [][]string
-	duplicates := 0
+	return &DedupResult{
+		Cleaned:    cleaned,
+		Dropped:    dropped,
+		Duplicates: len(dropped),
+	}, nil
+}
+
+// PhoneDeduper returns the per-row form of DedupPhones. The returned func
+// remembers every normalized phone it has kept and the line it came from,
+// which is the only state it holds; rows without a phone are always kept.
+func PhoneDeduper(schema *extract.Schema) (RowFunc, error) {
+	cols, err := schema.Require(extract.RolePhone)
+	if err != nil {
+		return nil, fmt.Errorf("dedup-phones: %w", err)
+	}
+	phoneIdx := cols[0]
 
-	// Always keep the header
-	uniqueRows = append(uniqueRows, ds.Headers)
+	seen := dedup.NewPhoneIndex() // phone -> source line of the row kept
 
-	for _, row := range ds.Rows {
-		if actualPhoneIdx >= len(row) {
+	return func(line int, row []string) ([]string, string) {
+		if phoneIdx >= len(row) {
 			// If phone column doesn't exist in this row, keep it
-			uniqueRows = append(uniqueRows, row)
-			continue
+			return row, ""
 		}
 
-		phone := normalizePhone(row[actualPhoneIdx])
+		phone, first, dup := seen.See(row[phoneIdx], line)
 		if phone == "" {
 			// If no phone number, keep the row
-			uniqueRows = append(uniqueRows, row)
-			continue
+			return row, ""
 		}
 
-		if seen[phone] {
-			duplicates++
-			continue // skip duplicate
+		if dup {
+			return row, fmt.Sprintf("%s:%d", types.ReasonDuplicateOf, first) // skip duplicate
 		}
 
-		seen[phone] = true
 		// Update the row with normalized phone number
-		newRow := make([]string, len(row))
-		copy(newRow, row)
-		newRow[actualPhoneIdx] = phone
-		uniqueRows = append(uniqueRows, newRow)
-	}
-
-	return &DedupResult{
-		Cleaned: &extract.DataSet{
-			Headers: ds.Headers,
-			Rows:    uniqueRows[1:], // Skip header row
-			Source:  ds.Source,
-		},
-		Duplicates: duplicates,
-	}
+		e := rowEdit{row: row}
+		e.set(phoneIdx, phone)
+		return e.row, ""
+	}, nil
 }
-
-// normalizePhone cleans and normalizes phone numbers
-func normalizePhone(phone string) string {
-	re := regexp.MustCompile(`\D`)
-	num := re.ReplaceAllString(phone, "")
-	if len(num) == 11 && num[0] == '1' {
-		num = num[1:]
-	}
-	return num
-}
\ No newline at end of file
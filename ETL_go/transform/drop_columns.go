@@ -49,31 +49,27 @@ func DropColumns(ds *extract.DataSet, indexes []int) *extract.DataSet {
 		toDrop[idx] = true
 	}
 
-	// Drop from headers
-	var newHeaders []string
-	for i, h := range ds.Headers {
-		if !toDrop[i] {
-			newHeaders = append(newHeaders, h)
-		}
+	// Drop from headers and rows
+	newHeaders := dropIndexes(ds.Headers, toDrop)
+	newRows := make([][]string, len(ds.Rows))
+	for i, row := range ds.Rows {
+		newRows[i] = dropIndexes(row, toDrop)
 	}
 
-	// Drop from rows
-	var newRows [][]string
-	for _, row := range ds.Rows {
-		var newRow []string
-		for i, val := range row {
-			if !toDrop[i] {
-				newRow = append(newRow, val)
-			}
-		}
-		newRows = append(newRows, newRow)
-	}
+	out := ds.WithRows(newRows, ds.Lines)
+	out.Headers = newHeaders
+	return out
+}
 
-	return &extract.DataSet{
-		Headers: newHeaders,
-		Rows:    newRows,
-		Source:  ds.Source,
+// dropIndexes returns a copy of values without the positions in toDrop.
+func dropIndexes(values []string, toDrop map[int]bool) []string {
+	var kept []string
+	for i, val := range values {
+		if !toDrop[i] {
+			kept = append(kept, val)
+		}
 	}
+	return kept
 }
 
 // ParseIndexes parses user input like "drop 0 3 6" into []int{0, 3, 6}.
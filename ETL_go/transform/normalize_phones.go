@@ -2,53 +2,69 @@ package transform
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
 
 	"etl_go/extract"
+	"etl_go/types"
+
+	"leadkit/phone"
 )
 
 // NormalizePhones cleans and normalizes phone numbers to a 10-digit numeric format.
 // It removes all non-digits and trims a leading '1' if the number has 11 digits.
-// Invalid or empty numbers are left as blank strings.
-func NormalizePhones(ds *extract.DataSet) *extract.DataSet {
+// Rows whose number breaks the NANP rules (see phone.Validate) are removed with
+// an invalid_phone:<problem> reason; empty numbers are left for final-validate.
+// Numbers with an area code missing from the table are only a warning: they
+// are kept, and UnknownAreaCodes counts them.
+func NormalizePhones(ds *extract.DataSet) (*extract.DataSet, []types.Reject, error) {
 	if ds == nil {
 		fmt.Println("No dataset loaded.")
-		return ds
+		return ds, nil, nil
 	}
 
-	// Phone number column index (based on 13-column schema)
-	const phoneIdx = 8
-
-	reDigits := regexp.MustCompile(`\D`) // matches all non-digit characters
-
-	newRows := make([][]string, len(ds.Rows))
-	for i, row := range ds.Rows {
-		newRow := make([]string, len(row))
-		copy(newRow, row)
-
-		if phoneIdx < len(row) && row[phoneIdx] != "" {
-			num := reDigits.ReplaceAllString(row[phoneIdx], "") // keep only digits
+	fn, err := PhoneNormalizer(ds.Schema())
+	if err != nil {
+		return ds, nil, err
+	}
+	cleaned, dropped := ApplyParallel(ds, fn)
+	return cleaned, dropped, nil
+}
 
-			// Remove leading "1" if 11 digits long (e.g. +1 country code)
-			if len(num) == 11 && strings.HasPrefix(num, "1") {
-				num = num[1:]
-			}
+// PhoneNormalizer returns the per-row form of NormalizePhones.
+func PhoneNormalizer(schema *extract.Schema) (RowFunc, error) {
+	cols, err := schema.Require(extract.RolePhone)
+	if err != nil {
+		return nil, fmt.Errorf("normalize-phones: %w", err)
+	}
+	phoneIdx := cols[0]
 
-			// Keep only valid 10-digit numbers
-			if len(num) == 10 {
-				newRow[phoneIdx] = num
-			} else {
-				newRow[phoneIdx] = ""
+	return func(line int, row []string) ([]string, string) {
+		e := rowEdit{row: row}
+		if phoneIdx < len(row) && strings.TrimSpace(row[phoneIdx]) != "" {
+			num, problem := phone.Validate(row[phoneIdx])
+			if problem != "" && !phone.Warning(problem) {
+				return row, types.ReasonInvalidPhone + ":" + problem
 			}
+			e.set(phoneIdx, num)
 		}
+		return e.row, ""
+	}, nil
+}
 
-		newRows[i] = newRow
+// UnknownAreaCodes counts the numbers in ds whose area code isn't in the
+// area code table, which NormalizePhones keeps.
+func UnknownAreaCodes(ds *extract.DataSet) int {
+	phoneIdx := ds.Schema().Lookup(extract.RolePhone)
+	if phoneIdx < 0 {
+		return 0
 	}
-
-	return &extract.DataSet{
-		Headers: ds.Headers,
-		Rows:    newRows,
-		Source:  ds.Source,
+	n := 0
+	for _, row := range ds.Rows {
+		if phoneIdx < len(row) && row[phoneIdx] != "" {
+			if _, problem := phone.Validate(row[phoneIdx]); problem == phone.Unknown {
+				n++
+			}
+		}
 	}
+	return n
 }
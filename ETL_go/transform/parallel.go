@@ -0,0 +1,107 @@
+package transform
+
+import (
+	"runtime"
+	"sync"
+
+	"etl_go/extract"
+	"etl_go/types"
+)
+
+// Workers is the number of goroutines ApplyParallel spreads rows over. It
+// defaults to GOMAXPROCS; 1 runs everything on the calling goroutine.
+var Workers = runtime.GOMAXPROCS(0)
+
+// chunkRows is the number of rows a worker takes at a time. Datasets no
+// bigger than one chunk run on the calling goroutine, where starting
+// workers would cost more than it saves.
+var chunkRows = 16384
+
+// chunkResult is what a worker produced for one chunk of rows.
+type chunkResult struct {
+	kept    [][]string
+	lines   []int
+	rejects []types.Reject
+}
+
+// ApplyParallel is ApplyRows spread over Workers goroutines. fn is shared by
+// every worker, so it must be safe for concurrent use: the stateless
+// cleaners are, while ones that count into stats or remember earlier rows
+// (NameCleaner, GeoPopulator, PhoneDeduper) are not. Kept rows and rejects
+// come back in the same order ApplyRows would give.
+func ApplyParallel(ds *extract.DataSet, fn RowFunc) (*extract.DataSet, []types.Reject) {
+	cleaned, rejects, _, _ := applyParallelStats(ds, func(*struct{}) (RowFunc, error) {
+		return fn, nil
+	})
+	return cleaned, rejects
+}
+
+// applyParallelStats runs a row-local transform over ds on several workers.
+// newFn is called once per worker with a zero S of its own, so counters the
+// RowFunc captures are never shared; the S of every worker is returned for
+// the caller to merge. An error from newFn is returned before any row runs.
+func applyParallelStats[S any](ds *extract.DataSet, newFn func(stats *S) (RowFunc, error)) (*extract.DataSet, []types.Reject, []S, error) {
+	chunks := (len(ds.Rows) + chunkRows - 1) / chunkRows
+	workers := max(1, min(Workers, chunks))
+
+	stats := make([]S, workers)
+	fns := make([]RowFunc, workers)
+	for w := range fns {
+		fn, err := newFn(&stats[w])
+		if err != nil {
+			return ds, nil, nil, err
+		}
+		fns[w] = fn
+	}
+	if workers == 1 {
+		cleaned, rejects := ApplyRows(ds, fns[0])
+		return cleaned, rejects, stats, nil
+	}
+
+	// Each chunk's result goes in its own slot, so the output order doesn't
+	// depend on which worker finishes first.
+	results := make([]chunkResult, chunks)
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for _, fn := range fns {
+		wg.Go(func() {
+			for c := range next {
+				results[c] = applyChunk(ds, fn, c*chunkRows, min((c+1)*chunkRows, len(ds.Rows)))
+			}
+		})
+	}
+	for c := range chunks {
+		next <- c
+	}
+	close(next)
+	wg.Wait()
+
+	kept := make([][]string, 0, len(ds.Rows))
+	lines := make([]int, 0, len(ds.Rows))
+	var rejects []types.Reject
+	for _, r := range results {
+		kept = append(kept, r.kept...)
+		lines = append(lines, r.lines...)
+		rejects = append(rejects, r.rejects...)
+	}
+	return ds.WithRows(kept, lines), rejects, stats, nil
+}
+
+// applyChunk runs fn over ds.Rows[start:end].
+func applyChunk(ds *extract.DataSet, fn RowFunc, start, end int) chunkResult {
+	r := chunkResult{
+		kept:  make([][]string, 0, end-start),
+		lines: make([]int, 0, end-start),
+	}
+	for i := start; i < end; i++ {
+		line := ds.Line(i)
+		out, reason := fn(line, ds.Rows[i])
+		if reason == "" {
+			r.kept = append(r.kept, out)
+			r.lines = append(r.lines, line)
+		} else {
+			r.rejects = append(r.rejects, types.Reject{Line: line, Reason: reason, Row: out, Headers: ds.Headers})
+		}
+	}
+	return r
+}
@@ -2,188 +2,177 @@ package transform
 
 import (
 	"fmt"
-	"strconv"
+	"slices"
 	"strings"
-	"unicode"
 
 	"etl_go/extract"
 	"etl_go/types"
-)
 
-// --- STATE → ZIP ---
-var stateZip = map[string]string{
-	"AL": "35007", "AK": "99501", "AZ": "85304", "AR": "71602", "CA": "90005",
-	"CO": "80001", "CT": "06001", "DE": "19701", "DC": "20012", "FL": "32003",
-	"GA": "30002", "HI": "96701", "ID": "83203", "IL": "61081", "IN": "46011",
-	"IA": "50005", "KS": "66008", "KY": "40007", "LA": "70001", "ME": "04750",
-	"MD": "20601", "MA": "05544", "MI": "48706", "MN": "54403", "MS": "38601",
-	"MO": "64722", "MT": "59001", "NE": "68001", "NV": "88905", "NH": "03031",
-	"NJ": "07753", "NC": "28376", "NM": "87001", "NY": "10028", "ND": "58001",
-	"OH": "45434", "OK": "73002", "OR": "97009", "PA": "15001", "RI": "02823",
-	"SC": "29001", "SD": "57002", "TN": "37011", "TX": "73344", "TX2": "79901",
-	"UT": "84002", "VT": "05009", "VA": "20101", "WA": "98001", "WV": "24712",
-	"WI": "54990", "WY": "82002", "PR": "00999", "VI": "00851",
-}
+	"leadkit/geodata"
+)
 
-// --- ZIP → STATE RANGE ---
-var zipCodeRanges = map[string][][2]int{
-	"AL": {{35000, 36999}}, "AK": {{99500, 99999}}, "AZ": {{85000, 86999}},
-	"AR": {{71600, 72999}}, "CA": {{90000, 96699}}, "CO": {{80000, 81999}},
-	"CT": {{6000, 6999}}, "DE": {{19700, 19999}}, "FL": {{32000, 34999}},
-	"GA": {{30000, 31999}, {39800, 39999}}, "HI": {{96700, 96999}},
-	"ID": {{83200, 83999}}, "IL": {{60000, 62999}}, "IN": {{46000, 47999}},
-	"IA": {{50000, 52999}}, "KS": {{66000, 67999}}, "KY": {{40000, 42999}},
-	"LA": {{70000, 71599}}, "ME": {{3900, 4999}}, "MD": {{20600, 21999}},
-	"MA": {{1000, 2799}, {5501, 5544}}, "MI": {{48000, 49999}},
-	"MN": {{55000, 56899}}, "MS": {{38600, 39999}}, "MO": {{63000, 65999}},
-	"MT": {{59000, 59999}}, "NC": {{27000, 28999}}, "ND": {{58000, 58999}},
-	"NE": {{68000, 69999}}, "NV": {{88900, 89999}}, "NH": {{3000, 3899}},
-	"NJ": {{7000, 8999}}, "NM": {{87000, 88499}},
-	"NY": {{10000, 14999}, {6390, 6390}, {501, 501}, {544, 544}},
-	"OH": {{43000, 45999}}, "OK": {{73000, 74999}}, "OR": {{97000, 97999}},
-	"PA": {{15000, 19699}}, "RI": {{2800, 2999}}, "SC": {{29000, 29999}},
-	"SD": {{57000, 57999}}, "TN": {{37000, 38599}},
-	"TX": {{75000, 79999}, {73301, 73399}, {88500, 88599}},
-	"UT": {{84000, 84999}}, "VT": {{5000, 5999}}, "VA": {{20100, 24699}},
-	"DC": {{20000, 20099}, {20200, 20599}, {56900, 56999}},
-	"WA": {{98000, 99499}}, "WV": {{24700, 26999}}, "WI": {{53000, 54999}},
-	"WY": {{82000, 83199}, {83414, 83414}}, "PR": {{600, 999}}, "VI": {{801, 851}},
+// GeoOptions controls what PopulateGeo may invent.
+type GeoOptions struct {
+	// PlaceholderZips fills a blank ZIP with a stand-in ZIP for the state.
+	// The result looks like a real address, so it is off unless asked for.
+	PlaceholderZips bool
 }
 
-// --- AREA CODE → STATE ---
-var stateAreaCodes = map[string][]string{
-	"AL": {"205", "251", "256", "334"},
-	"AK": {"907"},
-	"AZ": {"480", "520", "602", "623", "928"},
-	"AR": {"479", "501", "870"},
-	"CA": {"209", "213", "310", "323", "408", "415", "424", "442", "510", "559", "562", "619", "626", "650", "657", "661", "707", "714", "760", "805", "818", "831", "858", "909", "916", "925", "949", "951"},
-	"CO": {"303", "719", "720", "970"},
-	"CT": {"203", "475", "860", "959"},
-	"DC": {"202"},
-	"DE": {"302"},
-	"FL": {"305", "321", "352", "386", "407", "561", "727", "754", "772", "786", "813", "850", "863", "904", "941", "954"},
-	"GA": {"229", "404", "470", "478", "678", "706", "770", "912"},
-	"HI": {"808"},
-	"IL": {"217", "224", "309", "312", "331", "618", "630", "708", "773", "779", "815", "847"},
-	"NY": {"315", "332", "347", "516", "518", "585", "607", "631", "646", "716", "718", "845", "914", "917", "929"},
-	"TX": {"210", "214", "254", "281", "325", "346", "361", "409", "430", "432", "469", "512", "682", "713", "726", "737", "806", "817", "830", "832", "903", "936", "940", "956", "972", "979"},
+// ParseGeoOptions reads populate-geo's arguments.
+func ParseGeoOptions(args []string) (GeoOptions, error) {
+	var opts GeoOptions
+	for _, a := range args {
+		switch a {
+		case "placeholder-zips":
+			opts.PlaceholderZips = true
+		default:
+			return opts, fmt.Errorf("populate-geo: unknown option %q (expected placeholder-zips)", a)
+		}
+	}
+	return opts, nil
 }
 
-// --- HELPERS ---
-func normalizeState(state string) string {
-	return strings.ToUpper(strings.TrimSpace(state))
+// geoColumns holds the resolved positions of the columns PopulateGeo works on.
+// city and timezone are -1 when the dataset doesn't have them.
+type geoColumns struct {
+	state, zip, phone, city, timezone int
 }
 
-// hasLetters checks if a string contains any letters
-func hasLetters(s string) bool {
-	for _, char := range s {
-		if unicode.IsLetter(char) {
-			return true
+func (c geoColumns) populateZip(row []string) {
+	state := row[c.state]
+	if state != "" && row[c.zip] == "" {
+		if zip, ok := geodata.PlaceholderZip(state); ok {
+			row[c.zip] = zip
 		}
 	}
-	return false
 }
 
-// cleanZipCode removes invalid zip codes and returns a clean version or empty string
-func cleanZipCode(zip string) string {
-	zip = strings.TrimSpace(zip)
-
-	// If it contains letters, it's invalid
-	if hasLetters(zip) {
-		return ""
+func (c geoColumns) populateStateFromZip(row []string, stats *types.GeoStats) {
+	if row[c.zip] == "" {
+		return
 	}
-
-	// Extract only digits
-	var digits strings.Builder
-	for _, char := range zip {
-		if unicode.IsDigit(char) {
-			digits.WriteRune(char)
+	if state, fromRange := geodata.StateForZip(row[c.zip]); state != "" {
+		row[c.state] = state
+		if fromRange {
+			stats.StateFromZipRange++
 		}
 	}
+}
 
-	cleaned := digits.String()
-
-	// If it's too short (less than 5 digits), it's invalid
-	if len(cleaned) < 5 {
-		return ""
+func (c geoColumns) populateStateZipFromAreaCode(row []string, placeholderZip bool) {
+	ac, ok := phoneAreaCode(row[c.phone])
+	if !ok || ac.Country != "US" {
+		return
 	}
-
-	// Truncate to 5 digits if longer
-	if len(cleaned) > 5 {
-		cleaned = cleaned[:5]
+	row[c.state] = ac.Region
+	if placeholderZip {
+		row[c.zip], _ = geodata.PlaceholderZip(ac.Region)
 	}
-
-	return cleaned
 }
 
-func populateZip(row []string) {
-	state := row[6]
-	if state != "" && row[7] == "" {
-		if zip, ok := stateZip[state]; ok {
-			row[7] = zip
-		}
+// populateTimezone fills a blank timezone column from the ZIP code, or from
+// the phone's area code when the ZIP isn't known.
+func (c geoColumns) populateTimezone(row []string) {
+	if c.timezone < 0 || row[c.timezone] != "" {
+		return
+	}
+	if z, ok := geodata.LookupZip(row[c.zip]); ok && z.Timezone != "" {
+		row[c.timezone] = z.Timezone
+		return
+	}
+	if ac, ok := phoneAreaCode(row[c.phone]); ok {
+		row[c.timezone] = ac.Timezone
 	}
 }
 
-func populateStateFromZip(row []string) {
-	zipStr := row[7]
-	if zipStr == "" {
+// checkCity fills a blank city from the ZIP table and reports whether a
+// non-blank city disagrees with the ZIP's primary city. Mismatches are
+// only counted: a ZIP often covers places besides its primary city.
+func (c geoColumns) checkCity(row []string, stats *types.GeoStats) {
+	if c.city < 0 {
 		return
 	}
-	zipInt, err := strconv.Atoi(zipStr)
-	if err != nil {
+	z, ok := geodata.LookupZip(row[c.zip])
+	if !ok || z.State != row[c.state] {
 		return
 	}
-
-	for state, ranges := range zipCodeRanges {
-		for _, r := range ranges {
-			if zipInt >= r[0] && zipInt <= r[1] {
-				row[6] = state
-				return
-			}
-		}
+	city := strings.TrimSpace(row[c.city])
+	switch {
+	case city == "":
+		row[c.city] = z.City
+		stats.PopulatedCity++
+	case !strings.EqualFold(city, z.City):
+		stats.CityMismatches++
 	}
 }
 
-func populateStateZipFromAreaCode(row []string) {
-	if len(row[8]) < 3 {
-		return
+// phoneAreaCode looks up the area code of a normalized phone number.
+// Only geographic codes are returned.
+func phoneAreaCode(phone string) (geodata.AreaCode, bool) {
+	if len(phone) < 3 {
+		return geodata.AreaCode{}, false
 	}
-	ac := row[8][:3]
-	for state, codes := range stateAreaCodes {
-		for _, c := range codes {
-			if c == ac {
-				row[6] = state
-				row[7] = stateZip[state]
-				return
-			}
-		}
+	ac, ok := geodata.LookupAreaCode(phone[:3])
+	if !ok || ac.Kind != geodata.KindGeographic {
+		return geodata.AreaCode{}, false
 	}
+	return ac, true
 }
 
-// --- MAIN TRANSFORM FUNCTION ---
-func PopulateGeo(ds *extract.DataSet) (*extract.DataSet, types.GeoStats) {
+// PopulateGeo cleans ZIP codes and fills or corrects state, ZIP, city and
+// timezone from the geodata ZIP and area code tables.
+func PopulateGeo(ds *extract.DataSet, opts GeoOptions) (*extract.DataSet, types.GeoStats, error) {
 	if ds == nil {
 		fmt.Println("No dataset loaded.")
-		return ds, types.GeoStats{}
+		return ds, types.GeoStats{}, nil
 	}
 
+	schema := ds.Schema()
+	cleaned, _, perWorker, err := applyParallelStats(ds, func(stats *types.GeoStats) (RowFunc, error) {
+		return GeoPopulator(schema, opts, stats)
+	})
+	if err != nil {
+		return ds, types.GeoStats{}, err
+	}
 	stats := types.GeoStats{}
+	for _, w := range perWorker {
+		stats.Add(w)
+	}
+	return cleaned, stats, nil
+}
 
-	newRows := make([][]string, len(ds.Rows))
-	for i, row := range ds.Rows {
-		newRow := make([]string, len(row))
-		copy(newRow, row)
+// GeoPopulator returns the per-row form of PopulateGeo. Counters are added
+// to stats as rows go through it.
+func GeoPopulator(schema *extract.Schema, opts GeoOptions, stats *types.GeoStats) (RowFunc, error) {
+	cols, err := schema.Require(extract.RoleState, extract.RoleZip, extract.RolePhone)
+	if err != nil {
+		return nil, fmt.Errorf("populate-geo: %w", err)
+	}
+	c := geoColumns{
+		state:    cols[0],
+		zip:      cols[1],
+		phone:    cols[2],
+		city:     schema.Lookup(extract.RoleCity),
+		timezone: schema.Lookup(extract.RoleTimezone),
+	}
+	width := max(c.state, c.zip, c.phone, c.city, c.timezone) + 1
 
-		newRow[6] = normalizeState(newRow[6])
+	return func(line int, row []string) ([]string, string) {
+		if len(row) < width {
+			// Short row - leave it for validation to deal with
+			return row, ""
+		}
+		newRow := slices.Clone(row)
+
+		newRow[c.state] = geodata.NormalizeState(newRow[c.state])
 
 		// Clean the zip code first
-		originalZip := newRow[7]
-		newRow[7] = cleanZipCode(newRow[7])
+		originalZip := newRow[c.zip]
+		newRow[c.zip] = geodata.CleanZip(newRow[c.zip])
 
 		// Track what we cleaned
-		if originalZip != "" && newRow[7] == "" {
-			if hasLetters(originalZip) {
+		if originalZip != "" && newRow[c.zip] == "" {
+			if geodata.HasLetters(originalZip) {
 				stats.CleanedZipLetters++
 			} else {
 				stats.CleanedZipTooShort++
@@ -192,95 +181,53 @@ func PopulateGeo(ds *extract.DataSet) (*extract.DataSet, types.GeoStats) {
 
 		// Check for ZIP-State mismatch and correct it
 		hadMismatch := false
-		if newRow[6] != "" && newRow[7] != "" && isValidZip(newRow[7]) {
-			if !isValidZipForState(newRow[7], newRow[6]) {
-				// ZIP doesn't belong to this state - try to find correct state
-				if correctedState := findStateFromZip(newRow[7]); correctedState != "" {
-					newRow[6] = correctedState
-					stats.CorrectedMismatches++
-					hadMismatch = true
+		if newRow[c.state] != "" && newRow[c.zip] != "" && geodata.IsZip(newRow[c.zip]) {
+			// ZIP doesn't belong to this state - try to find correct state
+			if correctedState, fromRange := geodata.StateForZip(newRow[c.zip]); correctedState != "" && correctedState != newRow[c.state] {
+				newRow[c.state] = correctedState
+				stats.CorrectedMismatches++
+				if fromRange {
+					stats.StateFromZipRange++
 				}
+				hadMismatch = true
 			}
 		}
 
 		// Now populate missing data (only if we didn't just correct a mismatch)
 		if !hadMismatch {
-			if newRow[7] == "" && newRow[6] != "" {
-				populateZip(newRow)
-				if newRow[7] != "" {
+			if newRow[c.zip] == "" && newRow[c.state] != "" && opts.PlaceholderZips {
+				c.populateZip(newRow)
+				if newRow[c.zip] != "" {
 					stats.PopulatedZip++
 				}
 			}
-			if newRow[6] == "" && newRow[7] != "" {
-				populateStateFromZip(newRow)
-				if newRow[6] != "" {
+			if newRow[c.state] == "" && newRow[c.zip] != "" {
+				c.populateStateFromZip(newRow, stats)
+				if newRow[c.state] != "" {
 					stats.PopulatedState++
 				}
 			}
-			if (newRow[6] == "" || len(newRow[6]) != 2) && newRow[7] == "" {
-				populateStateZipFromAreaCode(newRow)
-				if newRow[6] != "" && newRow[7] != "" {
+			if (newRow[c.state] == "" || len(newRow[c.state]) != 2) && newRow[c.zip] == "" {
+				state, zip := newRow[c.state], newRow[c.zip]
+				c.populateStateZipFromAreaCode(newRow, opts.PlaceholderZips)
+				if newRow[c.state] != state || newRow[c.zip] != zip {
 					stats.FixedFromAreaCode++
 				}
 			}
 		}
 
-		newRows[i] = newRow
-	}
+		c.checkCity(newRow, stats)
 
-	return &extract.DataSet{
-		Headers: ds.Headers,
-		Rows:    newRows,
-		Source:  ds.Source,
-	}, stats
-}
-
-// Helper function to find state from ZIP
-func findStateFromZip(zipStr string) string {
-	zipInt, err := strconv.Atoi(zipStr)
-	if err != nil {
-		return ""
-	}
-
-	for state, ranges := range zipCodeRanges {
-		for _, r := range ranges {
-			if zipInt >= r[0] && zipInt <= r[1] {
-				return state
+		if c.timezone >= 0 && newRow[c.timezone] == "" {
+			c.populateTimezone(newRow)
+			if newRow[c.timezone] != "" {
+				stats.PopulatedTimezone++
 			}
 		}
-	}
-	return ""
-}
 
-// isValidZip checks if a zip code is valid (5 digits, all numeric)
-func isValidZip(zip string) bool {
-    if len(zip) != 5 {
-        return false
-    }
-    for _, char := range zip {
-        if !unicode.IsDigit(char) {
-            return false
-        }
-    }
-    return true
+		if slices.Equal(newRow, row) {
+			return row, ""
+		}
+		return newRow, ""
+	}, nil
 }
-
-// isValidZipForState checks if a ZIP code belongs to the given state
-func isValidZipForState(zipStr, state string) bool {
-    zipInt, err := strconv.Atoi(zipStr)
-    if err != nil {
-        return false
-    }
-
-    ranges, exists := zipCodeRanges[state]
-    if !exists {
-        return false
-    }
-
-    for _, r := range ranges {
-        if zipInt >= r[0] && zipInt <= r[1] {
-            return true
-        }
-    }
-    return false
-}
\ No newline at end of file
@@ -0,0 +1,300 @@
+package transform
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"etl_go/extract"
+
+	"leadkit/geodata"
+	"leadkit/phone"
+)
+
+// Types a column can be inferred as.
+const (
+	TypeEmpty   = "empty"
+	TypePhone   = "phone"
+	TypeZip     = "zip"
+	TypeEmail   = "email"
+	TypeState   = "state"
+	TypeDate    = "date"
+	TypeNumeric = "numeric"
+	TypeText    = "text"
+)
+
+// typeShare is the share of a column's filled values that must look like
+// one type for the column to be inferred as it; mixed columns are text.
+const typeShare = 0.9
+
+// maxDistinct caps the values counted per column, bounding memory on
+// columns where almost every value is unique.
+const maxDistinct = 100_000
+
+// topValues is the number of most common values kept per column.
+const topValues = 5
+
+var (
+	emailLike = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[A-Za-z]{2,}$`)
+	zipLike   = regexp.MustCompile(`^[0-9]{5}(-[0-9]{4})?$`)
+	phoneLike = regexp.MustCompile(`^[0-9\s()+.\-]+$`)
+)
+
+// dateLayouts are the date formats type inference recognizes.
+var dateLayouts = []string{
+	"2006-01-02", "2006/01/02", "01/02/2006", "1/2/2006", "01-02-2006",
+	"1/2/06", "Jan 2, 2006", "2 Jan 2006", "2006-01-02 15:04:05", time.RFC3339,
+}
+
+// ValueCount is one of a column's most common values.
+type ValueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// ValidatorRate is how many of a column's filled values a cleaning step
+// would blank or drop.
+type ValidatorRate struct {
+	Step     string  `json:"step"`
+	Rejected int     `json:"rejected"`
+	Rate     float64 `json:"rate"`
+}
+
+// ColumnProfile summarizes the values of one column.
+type ColumnProfile struct {
+	Index          int             `json:"index"`
+	Header         string          `json:"header"`
+	Role           extract.Role    `json:"role,omitempty"`
+	Type           string          `json:"type"`
+	Filled         int             `json:"filled"`
+	FillRate       float64         `json:"fill_rate"`
+	Distinct       int             `json:"distinct"`
+	DistinctCapped bool            `json:"distinct_capped,omitempty"` // more than maxDistinct values
+	MinLength      int             `json:"min_length"`
+	MaxLength      int             `json:"max_length"`
+	TopValues      []ValueCount    `json:"top_values"`
+	Rejects        []ValidatorRate `json:"rejects,omitempty"`
+}
+
+// Profile summarizes every column of a dataset.
+type Profile struct {
+	Source  string          `json:"source"`
+	Rows    int             `json:"rows"`
+	Columns []ColumnProfile `json:"columns"`
+}
+
+// validator is a cleaning step's test for a single value.
+type validator struct {
+	step   string
+	reject func(v string) bool
+}
+
+// validators lists, for each type, the steps that check it and what they
+// would blank or drop.
+var validators = map[string][]validator{
+	TypePhone: {
+		{"normalize-phones", func(v string) bool { _, problem := phone.Validate(v); return problem != "" && !phone.Warning(problem) }},
+	},
+	TypeZip: {
+		{"populate-geo", func(v string) bool { return geodata.CleanZip(v) == "" }},
+	},
+	TypeState: {
+		{"clean-states", func(v string) bool { return !isTwoLetterAlpha(strings.ToUpper(v)) }},
+		{"validate-states", func(v string) bool { return !AllowedStates[strings.ToUpper(v)] }},
+	},
+	TypeEmail: {
+		{"clean-email", digitsOnly.MatchString},
+	},
+}
+
+// roleTypes maps the roles the validators apply to onto their type, so a
+// mapped column is checked even when its values look like something else.
+var roleTypes = map[extract.Role]string{
+	extract.RolePhone: TypePhone,
+	extract.RoleZip:   TypeZip,
+	extract.RoleState: TypeState,
+	extract.RoleEmail: TypeEmail,
+}
+
+// ProfileDataSet reports each column's fill rate, distinct and most common
+// values, value lengths and inferred type, along with the share of values
+// the cleaning steps for that column's role or type would reject. Values
+// are trimmed first, and blank ones only count against the fill rate.
+func ProfileDataSet(ds *extract.DataSet) (*Profile, error) {
+	if ds == nil {
+		return nil, fmt.Errorf("no dataset loaded")
+	}
+	schema := ds.Schema()
+	roles := make(map[int]extract.Role)
+	for _, role := range extract.Roles {
+		if i := schema.Lookup(role); i >= 0 {
+			roles[i] = role
+		}
+	}
+
+	p := &Profile{Source: ds.Source, Rows: len(ds.Rows)}
+	for j, header := range ds.Headers {
+		p.Columns = append(p.Columns, profileColumn(ds.Rows, j, header, roles[j]))
+	}
+	return p, nil
+}
+
+func profileColumn(rows [][]string, j int, header string, role extract.Role) ColumnProfile {
+	c := ColumnProfile{Index: j, Header: header, Role: role}
+	counts := make(map[string]int)
+	kinds := make(map[string]int) // values per valueType
+	for _, row := range rows {
+		v := field(row, j)
+		if v == "" {
+			continue
+		}
+		n := utf8.RuneCountInString(v)
+		if c.Filled == 0 || n < c.MinLength {
+			c.MinLength = n
+		}
+		c.MaxLength = max(c.MaxLength, n)
+		c.Filled++
+		if _, ok := counts[v]; ok || len(counts) < maxDistinct {
+			counts[v]++
+		} else {
+			c.DistinctCapped = true
+		}
+		kinds[valueType(v)]++
+	}
+	if len(rows) > 0 {
+		c.FillRate = float64(c.Filled) / float64(len(rows))
+	}
+	c.Distinct = len(counts)
+	c.Type = columnType(kinds, c.Filled)
+
+	top := slices.SortedFunc(maps.Keys(counts), func(a, b string) int {
+		return cmp.Or(cmp.Compare(counts[b], counts[a]), cmp.Compare(a, b))
+	})
+	for _, v := range top[:min(topValues, len(top))] {
+		c.TopValues = append(c.TopValues, ValueCount{Value: v, Count: counts[v]})
+	}
+
+	kind := c.Type
+	if t, ok := roleTypes[role]; ok {
+		kind = t
+	}
+	// A second pass, since which validators apply depends on the type
+	for _, val := range validators[kind] {
+		r := ValidatorRate{Step: val.step}
+		for _, row := range rows {
+			if v := field(row, j); v != "" && val.reject(v) {
+				r.Rejected++
+			}
+		}
+		if c.Filled > 0 {
+			r.Rate = float64(r.Rejected) / float64(c.Filled)
+		}
+		c.Rejects = append(c.Rejects, r)
+	}
+	return c
+}
+
+// valueType guesses what a single trimmed value is.
+func valueType(v string) string {
+	switch {
+	case emailLike.MatchString(v):
+		return TypeEmail
+	case zipLike.MatchString(v):
+		return TypeZip
+	case phoneLike.MatchString(v) && len(phone.Normalize(v)) == 10:
+		return TypePhone
+	case AllowedStates[strings.ToUpper(v)]:
+		return TypeState
+	case isDate(v):
+		return TypeDate
+	}
+	if _, err := strconv.ParseFloat(strings.ReplaceAll(v, ",", ""), 64); err == nil {
+		return TypeNumeric
+	}
+	return TypeText
+}
+
+func isDate(v string) bool {
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, v); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// columnType picks the type most values share, or text when none reaches
+// typeShare.
+func columnType(kinds map[string]int, filled int) string {
+	if filled == 0 {
+		return TypeEmpty
+	}
+	for t, n := range kinds {
+		if float64(n) >= typeShare*float64(filled) {
+			return t
+		}
+	}
+	return TypeText
+}
+
+// Lines renders the profile as a table, one line per column, for the
+// output window.
+func (p *Profile) Lines() []string {
+	lines := []string{
+		fmt.Sprintf("Profile of %s: %d rows, %d columns", p.Source, p.Rows, len(p.Columns)),
+		fmt.Sprintf("%3s  %-16s %-8s %6s  %9s  %-7s %-24s %s", "#", "Column", "Type", "Fill", "Distinct", "Length", "Top value", "Would reject"),
+	}
+	for _, c := range p.Columns {
+		top := ""
+		if len(c.TopValues) > 0 {
+			top = fmt.Sprintf("%q x%d", extract.FitCell(c.TopValues[0].Value, 16), c.TopValues[0].Count)
+		}
+		var rejects []string
+		for _, r := range c.Rejects {
+			rejects = append(rejects, fmt.Sprintf("%s %.1f%%", r.Step, 100*r.Rate))
+		}
+		line := fmt.Sprintf("%3d  %-16s %-8s %5.1f%%  %9s  %-7s %-24s %s",
+			c.Index, extract.FitCell(c.Header, 16), c.Type, 100*c.FillRate, c.distinct(),
+			fmt.Sprintf("%d-%d", c.MinLength, c.MaxLength), top, strings.Join(rejects, ", "))
+		lines = append(lines, strings.TrimRight(line, " "))
+	}
+	return lines
+}
+
+// Lines describes one column in full.
+func (c ColumnProfile) Lines() []string {
+	name := fmt.Sprintf("[%d] %s", c.Index, c.Header)
+	if c.Role != "" {
+		name += fmt.Sprintf(" (%s)", c.Role)
+	}
+	lines := []string{
+		fmt.Sprintf("%s: %s", name, c.Type),
+		fmt.Sprintf("  Filled:   %d (%.1f%%)", c.Filled, 100*c.FillRate),
+		fmt.Sprintf("  Distinct: %s", c.distinct()),
+		fmt.Sprintf("  Length:   %d-%d", c.MinLength, c.MaxLength),
+	}
+	if len(c.TopValues) > 0 {
+		lines = append(lines, "  Top values:")
+		for _, v := range c.TopValues {
+			lines = append(lines, fmt.Sprintf("    %6d  %q", v.Count, v.Value))
+		}
+	}
+	for _, r := range c.Rejects {
+		lines = append(lines, fmt.Sprintf("  %s would reject %d (%.1f%%)", r.Step, r.Rejected, 100*r.Rate))
+	}
+	return lines
+}
+
+// distinct formats the distinct count, marking it when it hit maxDistinct.
+func (c ColumnProfile) distinct() string {
+	if c.DistinctCapped {
+		return strconv.Itoa(c.Distinct) + "+"
+	}
+	return strconv.Itoa(c.Distinct)
+}
@@ -0,0 +1,182 @@
+package transform
+
+import (
+	"cmp"
+	"strings"
+	"unicode"
+)
+
+// jaroWinkler scores two strings from 0 (nothing in common) to 1 (equal),
+// favoring strings that share a prefix, which suits names and typos in them.
+func jaroWinkler(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	s, t := []rune(a), []rune(b)
+	if len(s) == 0 || len(t) == 0 {
+		return 0
+	}
+
+	window := max(len(s), len(t))/2 - 1
+	window = max(window, 0)
+	sMatched := make([]bool, len(s))
+	tMatched := make([]bool, len(t))
+	matches := 0
+	for i := range s {
+		lo, hi := max(0, i-window), min(len(t), i+window+1)
+		for j := lo; j < hi; j++ {
+			if !tMatched[j] && s[i] == t[j] {
+				sMatched[i], tMatched[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	// Transpositions: matched runes that appear in a different order
+	transposed, j := 0, 0
+	for i := range s {
+		if !sMatched[i] {
+			continue
+		}
+		for !tMatched[j] {
+			j++
+		}
+		if s[i] != t[j] {
+			transposed++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(s)) + m/float64(len(t)) + (m-float64(transposed)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(s), len(t)) && s[prefix] == t[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// nicknameGroups lists common nicknames under the name they are short for.
+var nicknameGroups = map[string][]string{
+	"ALEXANDER":   {"ALEX"},
+	"ANDREW":      {"ANDY", "DREW"},
+	"ANTHONY":     {"TONY"},
+	"BARBARA":     {"BARB", "BARBIE"},
+	"BENJAMIN":    {"BEN", "BENNY"},
+	"CHARLES":     {"CHARLIE", "CHUCK", "CHAS"},
+	"CHRISTOPHER": {"CHRIS"},
+	"CYNTHIA":     {"CINDY"},
+	"DANIEL":      {"DAN", "DANNY"},
+	"DAVID":       {"DAVE", "DAVEY"},
+	"DEBORAH":     {"DEB", "DEBBIE", "DEBRA"},
+	"DONALD":      {"DON", "DONNIE"},
+	"EDWARD":      {"ED", "EDDIE", "TED", "TEDDY"},
+	"ELIZABETH":   {"LIZ", "LIZZIE", "BETH", "BETTY", "ELIZA"},
+	"GERALD":      {"GERRY", "JERRY"},
+	"GREGORY":     {"GREG"},
+	"HENRY":       {"HANK", "HARRY"},
+	"JAMES":       {"JIM", "JIMMY", "JAMIE"},
+	"JENNIFER":    {"JEN", "JENNY"},
+	"JOHN":        {"JOHNNY", "JACK"},
+	"JOSEPH":      {"JOE", "JOEY"},
+	"KATHERINE":   {"KATE", "KATIE", "KATHY", "CATHY", "CATHERINE", "KATHRYN"},
+	"KENNETH":     {"KEN", "KENNY"},
+	"LAWRENCE":    {"LARRY"},
+	"MARGARET":    {"MAGGIE", "MEG", "PEGGY"},
+	"MATTHEW":     {"MATT"},
+	"MICHAEL":     {"MIKE", "MIKEY", "MICKEY"},
+	"NICHOLAS":    {"NICK", "NICKY"},
+	"PAMELA":      {"PAM"},
+	"PATRICIA":    {"PATTY", "PATSY", "TRISH"},
+	"RICHARD":     {"RICK", "RICKY", "RICH", "DICK"},
+	"ROBERT":      {"ROB", "ROBBIE", "BOB", "BOBBY"},
+	"RONALD":      {"RON", "RONNIE"},
+	"SAMUEL":      {"SAM", "SAMMY"},
+	"SANDRA":      {"SANDY"},
+	"STEVEN":      {"STEVE", "STEPHEN"},
+	"SUSAN":       {"SUE", "SUSIE", "SUZY"},
+	"THOMAS":      {"TOM", "TOMMY"},
+	"VICTORIA":    {"VICKY", "VICKIE", "TORI"},
+	"WILLIAM":     {"BILL", "BILLY", "WILL", "WILLY", "LIAM"},
+}
+
+// nicknames maps each nickname to the name it is short for.
+var nicknames = func() map[string]string {
+	m := make(map[string]string)
+	for name, nicks := range nicknameGroups {
+		for _, n := range nicks {
+			m[n] = name
+		}
+	}
+	return m
+}()
+
+// firstNameSimilarity compares two normalized first names, treating a
+// nickname and the name it stands for (Bob, Robert) as equal.
+func firstNameSimilarity(a, b string) float64 {
+	return jaroWinkler(cmp.Or(nicknames[a], a), cmp.Or(nicknames[b], b))
+}
+
+// addressAbbrev maps spelled-out address words to their USPS abbreviations.
+var addressAbbrev = map[string]string{
+	"STREET": "ST", "AVENUE": "AVE", "ROAD": "RD", "DRIVE": "DR", "LANE": "LN",
+	"BOULEVARD": "BLVD", "COURT": "CT", "PLACE": "PL", "CIRCLE": "CIR",
+	"PARKWAY": "PKWY", "HIGHWAY": "HWY", "TERRACE": "TER", "TRAIL": "TRL",
+	"NORTH": "N", "SOUTH": "S", "EAST": "E", "WEST": "W",
+	"APARTMENT": "APT", "SUITE": "STE", "UNIT": "APT",
+}
+
+// normalizeAddress upper-cases an address, drops punctuation and
+// abbreviates common words, so "12 North Main Street" and "12 N. Main St"
+// compare equal.
+func normalizeAddress(s string) string {
+	fields := strings.FieldsFunc(strings.ToUpper(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, f := range fields {
+		if abbr, ok := addressAbbrev[f]; ok {
+			fields[i] = abbr
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// addressSimilarity compares two normalized addresses. Different house
+// numbers mean different addresses however alike the street names are.
+func addressSimilarity(a, b string) float64 {
+	numA, _, _ := strings.Cut(a, " ")
+	numB, _, _ := strings.Cut(b, " ")
+	if isDigits(numA) && isDigits(numB) && numA != numB {
+		return 0
+	}
+	return jaroWinkler(a, b)
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeName upper-cases a name and keeps only its letters.
+func normalizeName(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		if unicode.IsLetter(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,144 @@
+package transform
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"etl_go/extract"
+	"etl_go/types"
+)
+
+// RowFunc processes a single row read from the given source line. It returns
+// the resulting row and, when the row should be dropped, a reason code (see
+// types.Reason*); an empty reason keeps the row. It must never modify the row
+// it is given: when a field changes it returns a copy, so unchanged rows can
+// be passed through as-is.
+type RowFunc func(line int, row []string) ([]string, string)
+
+// RowWriter receives the rows that survive a streaming pipeline.
+// load.CSVSink satisfies it.
+type RowWriter interface {
+	Write(row []string) error
+}
+
+// StreamStats counts the rows that went through Stream.
+type StreamStats struct {
+	Read    int
+	Written int
+	Dropped int
+}
+
+// Stream pulls rows from src, runs each one through fns in order, and writes
+// the survivors to dst. Dropped rows are passed to onReject when it is not nil.
+// Only one row is in flight at a time, so memory use depends on the steps'
+// own state (e.g. the dedup phone set), not file size.
+func Stream(src extract.RowSource, dst RowWriter, onReject func(types.Reject), fns ...RowFunc) (StreamStats, error) {
+	var stats StreamStats
+	for {
+		row, err := src.Next()
+		if err == io.EOF {
+			return stats, nil
+		}
+		if err != nil {
+			return stats, fmt.Errorf("row %d: %w", stats.Read+1, err)
+		}
+		stats.Read++
+
+		line := src.Line()
+		row, reason := applyFuncs(line, row, fns)
+		if reason != "" {
+			stats.Dropped++
+			if onReject != nil {
+				onReject(types.Reject{Line: line, Reason: reason, Row: row, Headers: src.Headers()})
+			}
+			continue
+		}
+		if err := dst.Write(row); err != nil {
+			return stats, err
+		}
+		stats.Written++
+	}
+}
+
+// applyFuncs runs row through fns, stopping at the first one that drops it.
+func applyFuncs(line int, row []string, fns []RowFunc) ([]string, string) {
+	for _, fn := range fns {
+		var reason string
+		row, reason = fn(line, row)
+		if reason != "" {
+			return row, reason
+		}
+	}
+	return row, ""
+}
+
+// ApplyRows runs fn over every row of ds and returns the kept rows as a new
+// DataSet along with a Reject for every row that was dropped.
+func ApplyRows(ds *extract.DataSet, fn RowFunc) (*extract.DataSet, []types.Reject) {
+	kept := make([][]string, 0, len(ds.Rows))
+	lines := make([]int, 0, len(ds.Rows))
+	var rejects []types.Reject
+	for i, row := range ds.Rows {
+		line := ds.Line(i)
+		out, reason := fn(line, row)
+		if reason == "" {
+			kept = append(kept, out)
+			lines = append(lines, line)
+		} else {
+			rejects = append(rejects, types.Reject{Line: line, Reason: reason, Row: out, Headers: ds.Headers})
+		}
+	}
+	return ds.WithRows(kept, lines), rejects
+}
+
+// rowEdit sets fields on a row, copying it the first time a value actually
+// changes so the caller's row is never modified.
+type rowEdit struct {
+	row    []string
+	copied bool
+}
+
+func (e *rowEdit) set(i int, val string) {
+	if e.row[i] == val {
+		return
+	}
+	if !e.copied {
+		e.row = slices.Clone(e.row)
+		e.copied = true
+	}
+	e.row[i] = val
+}
+
+// dropColumnsSource removes columns from every row of the wrapped source.
+type dropColumnsSource struct {
+	extract.RowSource
+	toDrop  map[int]bool
+	headers []string
+}
+
+// DropColumnsSource wraps src so the given column indexes are removed from its
+// headers and rows, the streaming counterpart of DropColumns.
+func DropColumnsSource(src extract.RowSource, indexes []int) extract.RowSource {
+	toDrop := make(map[int]bool)
+	for _, idx := range indexes {
+		toDrop[idx] = true
+	}
+	return &dropColumnsSource{
+		RowSource: src,
+		toDrop:    toDrop,
+		headers:   dropIndexes(src.Headers(), toDrop),
+	}
+}
+
+func (s *dropColumnsSource) Headers() []string {
+	return s.headers
+}
+
+func (s *dropColumnsSource) Next() ([]string, error) {
+	row, err := s.RowSource.Next()
+	if err != nil {
+		return nil, err
+	}
+	return dropIndexes(row, s.toDrop), nil
+}
@@ -1,7 +1,14 @@
 package transform
 
 import (
+	"context"
 	"etl_go/extract"
+	"etl_go/types"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
 	"testing"
 )
 
@@ -32,7 +39,10 @@ func mockData() *extract.DataSet {
 
 func TestCleanAddresses(t *testing.T) {
 	ds := mockData()
-	got := CleanAddresses(ds)
+	got, err := CleanAddresses(ds)
+	if err != nil {
+		t.Fatalf("CleanAddresses returned error: %v", err)
+	}
 	if got.Rows[1][4] != "77## Weird Blvd" {
 		t.Errorf("row 1: expected '77## Weird Blvd', got '%s'", got.Rows[1][4])
 	}
@@ -43,7 +53,10 @@ func TestCleanAddresses(t *testing.T) {
 
 func TestCleanEmails(t *testing.T) {
 	ds := mockData()
-	got := CleanEmails(ds)
+	got, err := CleanEmails(ds)
+	if err != nil {
+		t.Fatalf("CleanEmails returned error: %v", err)
+	}
 	if got.Rows[5][11] != "" {
 		t.Errorf("row 5: expected blank email for numeric value, got '%s'", got.Rows[5][11])
 	}
@@ -54,7 +67,10 @@ func TestCleanEmails(t *testing.T) {
 
 func TestCleanNames(t *testing.T) {
 	ds := mockData()
-	got := CleanNames(ds)
+	got, stats, err := CleanNames(ds)
+	if err != nil {
+		t.Fatalf("CleanNames returned error: %v", err)
+	}
 
 	tests := []struct {
 		row, col int
@@ -72,11 +88,18 @@ func TestCleanNames(t *testing.T) {
 			t.Errorf("row %d col %d: expected %q, got %q", tt.row, tt.col, tt.want, got.Rows[tt.row][tt.col])
 		}
 	}
+	// J4son is cleared, "K." loses its period
+	if stats.CleanedNumeric != 1 || stats.CleanedSpecial != 1 {
+		t.Errorf("expected 1 numeric and 1 special cleanup, got %+v", stats)
+	}
 }
 
 func TestCleanStates(t *testing.T) {
 	ds := mockData()
-	got := CleanStates(ds)
+	got, err := CleanStates(ds)
+	if err != nil {
+		t.Fatalf("CleanStates returned error: %v", err)
+	}
 	if got.Rows[0][6] != "" {
 		t.Errorf("row 0: expected blank for invalid 'florida', got '%s'", got.Rows[0][6])
 	}
@@ -87,16 +110,44 @@ func TestCleanStates(t *testing.T) {
 
 func TestNormalizePhones(t *testing.T) {
 	ds := mockData()
-	got := NormalizePhones(ds)
+	got, dropped, err := NormalizePhones(ds)
+	if err != nil {
+		t.Fatalf("NormalizePhones returned error: %v", err)
+	}
 
 	if got.Rows[0][8] != "8135559999" {
 		t.Errorf("expected 8135559999, got '%s'", got.Rows[0][8])
 	}
-	if got.Rows[4][8] != "3218881212" {
-		t.Errorf("expected 3218881212, got '%s'", got.Rows[4][8])
+	// 555-555-5555 (line 4) and 999 (line 5) are removed
+	if got.Rows[2][8] != "3218881212" {
+		t.Errorf("expected 3218881212, got '%s'", got.Rows[2][8])
 	}
-	if got.Rows[3][8] != "" {
-		t.Errorf("expected blank invalid number, got '%s'", got.Rows[3][8])
+	if len(dropped) != 2 || dropped[0].Reason != "invalid_phone:unassigned_area_code" || dropped[1].Reason != "invalid_phone:length" {
+		t.Errorf("unexpected rejects: %+v", dropped)
+	}
+	if got.Rows[4][8] != "" {
+		t.Errorf("expected empty number to be kept blank, got '%s'", got.Rows[4][8])
+	}
+}
+
+func TestNormalizePhonesKeepsUnknownAreaCodes(t *testing.T) {
+	ds := &extract.DataSet{
+		Headers: []string{"phone"},
+		Rows:    [][]string{{"(366) 555-1234"}, {"370-555-1234"}, {"813-555-9999"}},
+	}
+	got, dropped, err := NormalizePhones(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 366 isn't in the table but could be a new code; 370 is reserved
+	if len(got.Rows) != 2 || got.Rows[0][0] != "3665551234" {
+		t.Errorf("unexpected rows: %v", got.Rows)
+	}
+	if len(dropped) != 1 || dropped[0].Reason != "invalid_phone:unassigned_area_code" {
+		t.Errorf("unexpected rejects: %+v", dropped)
+	}
+	if n := UnknownAreaCodes(got); n != 1 {
+		t.Errorf("UnknownAreaCodes = %d, want 1", n)
 	}
 }
 
@@ -110,13 +161,142 @@ func TestDedupPhones(t *testing.T) {
 			{"4", "D", "", "Z", "", "", "TX", "73301", "5125550000", "", "", "d@z.com", ""},
 		},
 	}
-	res := DedupPhones(ds)
+	res, err := DedupPhones(ds)
+	if err != nil {
+		t.Fatalf("DedupPhones returned error: %v", err)
+	}
 	if res.Duplicates != 2 {
 		t.Errorf("expected 2 duplicates removed, got %d", res.Duplicates)
 	}
 	if len(res.Cleaned.Rows) != 2 {
 		t.Errorf("expected 2 unique rows, got %d", len(res.Cleaned.Rows))
 	}
+
+	// Data starts on line 2, so rows 2 and 4 duplicate lines 2 and 4.
+	want := []types.Reject{
+		{Line: 3, Reason: "duplicate_of:2"},
+		{Line: 5, Reason: "duplicate_of:4"},
+	}
+	if len(res.Dropped) != len(want) {
+		t.Fatalf("expected %d rejects, got %d", len(want), len(res.Dropped))
+	}
+	for i, w := range want {
+		got := res.Dropped[i]
+		if got.Line != w.Line || got.Reason != w.Reason {
+			t.Errorf("reject %d = line %d %q, want line %d %q", i, got.Line, got.Reason, w.Line, w.Reason)
+		}
+	}
+}
+
+func TestDedupExisting(t *testing.T) {
+	set := NewPhoneSet()
+	set.Add("(813) 555-9999", "dnc:system")
+	set.Add("1-813-555-9999", "existing_lead:list_1001") // already DNC, keeps that reason
+	set.Add("5125558888", "existing_lead:list_1001")
+
+	cleaned, dropped, err := DedupExisting(mockData(), set)
+	if err != nil {
+		t.Fatalf("DedupExisting returned error: %v", err)
+	}
+	if got, want := len(cleaned.Rows), len(mockData().Rows)-2; got != want {
+		t.Errorf("expected %d rows kept, got %d", want, got)
+	}
+
+	want := []types.Reject{
+		{Line: 2, Reason: "dnc:system"},
+		{Line: 3, Reason: "existing_lead:list_1001"},
+	}
+	if len(dropped) != len(want) {
+		t.Fatalf("expected %d rejects, got %d", len(want), len(dropped))
+	}
+	for i, w := range want {
+		if got := dropped[i]; got.Line != w.Line || got.Reason != w.Reason {
+			t.Errorf("reject %d = line %d %q, want line %d %q", i, got.Line, got.Reason, w.Line, w.Reason)
+		}
+	}
+}
+
+func TestDedupFuzzy(t *testing.T) {
+	ds := &extract.DataSet{
+		Headers: []string{"first_name", "last_name", "address1", "zip", "email", "phone"},
+		Rows: [][]string{
+			{"Robert", "Johnson", "12 North Main Street", "33101", "", "3055550001"},
+			{"Bob", "Johnsen", "12 N. Main St", "33101", "bob@example.com", "3055550002"},
+			{"Robert", "Johnson", "14 North Main Street", "33101", "", "3055550003"}, // different house
+			{"Mary", "Smith", "9 Oak Ave", "33101", "mary@example.com", "3055550004"},
+			{"Mary", "Smith", "", "33101", "", "3055550005"}, // name alone isn't enough
+			{"Maryann", "Smithe", "", "33101", "mary@example.com", "3055550006"},
+		},
+	}
+
+	flagged, err := DedupFuzzy(context.Background(), ds, FuzzyOptions{Block: BlockZip, Threshold: DefaultFuzzyThreshold})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flagged.Cleaned != ds || len(flagged.Dropped) != 0 {
+		t.Error("flag mode should leave the dataset alone")
+	}
+	var got [][]int
+	for _, c := range flagged.Clusters {
+		var lines []int
+		for _, m := range c.Members {
+			lines = append(lines, m.Line)
+		}
+		got = append(got, lines)
+	}
+	if want := [][]int{{2, 3}, {5, 7}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("clusters = %v, want %v", got, want)
+	}
+
+	merged, err := DedupFuzzy(context.Background(), ds, FuzzyOptions{Block: BlockLast, Threshold: DefaultFuzzyThreshold, Merge: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged.Cleaned.Rows) != 4 || len(merged.Dropped) != 2 {
+		t.Fatalf("merge kept %d rows and dropped %d, want 4 and 2", len(merged.Cleaned.Rows), len(merged.Dropped))
+	}
+	if r := merged.Dropped[0]; r.Line != 3 || r.Reason != "fuzzy_duplicate_of:2" {
+		t.Errorf("first reject = line %d %q", r.Line, r.Reason)
+	}
+	if email := merged.Cleaned.Rows[0][4]; email != "bob@example.com" {
+		t.Errorf("kept row's blank email should be filled from its match, got %q", email)
+	}
+	if ds.Rows[0][4] != "" {
+		t.Error("merge modified the input row")
+	}
+}
+
+func TestDedupFuzzyStopsWhenCancelled(t *testing.T) {
+	ds := &extract.DataSet{
+		Headers: []string{"first_name", "last_name", "zip"},
+		Rows:    [][]string{{"Ann", "Lee", "33101"}, {"Ann", "Lee", "33101"}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := DedupFuzzy(ctx, ds, FuzzyOptions{Block: BlockZip, Threshold: DefaultFuzzyThreshold}); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestJaroWinkler(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"MARTHA", "MARHTA", 0.961},
+		{"DWAYNE", "DUANE", 0.840},
+		{"DIXON", "DICKSONX", 0.813},
+		{"SMITH", "SMITH", 1},
+		{"ABC", "", 0},
+	}
+	for _, tt := range tests {
+		if got := jaroWinkler(tt.a, tt.b); math.Abs(got-tt.want) > 0.001 {
+			t.Errorf("jaroWinkler(%q, %q) = %.3f, want %.3f", tt.a, tt.b, got, tt.want)
+		}
+	}
+	if firstNameSimilarity("BOB", "ROBERT") != 1 {
+		t.Error("Bob and Robert should match as nicknames")
+	}
 }
 
 func TestDropColumns(t *testing.T) {
@@ -128,9 +308,51 @@ func TestDropColumns(t *testing.T) {
 	}
 }
 
+func TestTransformsFollowColumnsAfterDrop(t *testing.T) {
+	// Dropping SourceID and Middle shifts every later column to the left.
+	ds := DropColumns(mockData(), []int{0, 2})
+
+	names, _, err := CleanNames(ds)
+	if err != nil {
+		t.Fatalf("CleanNames returned error: %v", err)
+	}
+	if names.Rows[0][0] != "" {
+		t.Errorf("expected J4son to be cleared from the shifted first name column, got %q", names.Rows[0][0])
+	}
+	if names.Rows[0][1] != "Hall" {
+		t.Errorf("expected last name Hall to be untouched, got %q", names.Rows[0][1])
+	}
+
+	phones, _, err := NormalizePhones(ds)
+	if err != nil {
+		t.Fatalf("NormalizePhones returned error: %v", err)
+	}
+	if phones.Rows[0][6] != "8135559999" {
+		t.Errorf("expected phone normalized in shifted column, got %q", phones.Rows[0][6])
+	}
+}
+
+func TestTransformsReportMissingColumn(t *testing.T) {
+	// Drop the Phone column entirely.
+	ds := DropColumns(mockData(), []int{8})
+
+	if _, _, err := NormalizePhones(ds); err == nil {
+		t.Error("expected NormalizePhones to fail without a phone column")
+	}
+	if _, err := DedupPhones(ds); err == nil {
+		t.Error("expected DedupPhones to fail without a phone column")
+	}
+	if _, err := CleanAddresses(ds); err != nil {
+		t.Errorf("CleanAddresses should not need a phone column: %v", err)
+	}
+}
+
 func TestPopulateGeo(t *testing.T) {
 	ds := mockData()
-	got, stats := PopulateGeo(ds)
+	got, stats, err := PopulateGeo(ds, GeoOptions{})
+	if err != nil {
+		t.Fatalf("PopulateGeo returned error: %v", err)
+	}
 	if stats.PopulatedZip+stats.PopulatedState+stats.FixedFromAreaCode+stats.CorrectedMismatches == 0 {
 		t.Log("No geo fields were populated or fixed — dataset may already be clean")
 	}
@@ -140,6 +362,74 @@ func TestPopulateGeo(t *testing.T) {
 	}
 }
 
+func TestPopulateGeoUsesAreaCodeTable(t *testing.T) {
+	ds := &extract.DataSet{
+		Headers: []string{"State", "Zip", "Phone", "Timezone"},
+		Rows: [][]string{
+			{"", "", "2065551234", ""},                  // Seattle, outside the old 15-state list
+			{"FL", "32501", "8505551234", ""},           // panhandle is Central time
+			{"TX", "73301", "5125551234", "US/Central"}, // existing timezone is kept
+			{"ONT", "", "4165551234", ""},               // Canadian area code leaves the state alone
+		},
+	}
+	got, stats, err := PopulateGeo(ds, GeoOptions{})
+	if err != nil {
+		t.Fatalf("PopulateGeo returned error: %v", err)
+	}
+	if got.Rows[0][0] != "WA" || got.Rows[0][3] != "America/Los_Angeles" {
+		t.Errorf("row 0: expected WA / America/Los_Angeles, got %v", got.Rows[0])
+	}
+	if got.Rows[1][3] != "America/Chicago" {
+		t.Errorf("row 1: expected America/Chicago, got %q", got.Rows[1][3])
+	}
+	if got.Rows[2][3] != "US/Central" {
+		t.Errorf("row 2: timezone was overwritten with %q", got.Rows[2][3])
+	}
+	if got.Rows[3][0] != "ONT" {
+		t.Errorf("row 3: state was changed to %q", got.Rows[3][0])
+	}
+	if stats.FixedFromAreaCode != 1 || stats.PopulatedTimezone != 3 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestPopulateGeoUsesZipTable(t *testing.T) {
+	ds := &extract.DataSet{
+		Headers: []string{"City", "State", "Zip", "Phone"},
+		Rows: [][]string{
+			{"", "FL", "33610", "8135559999"},          // blank city is filled
+			{"Ybor City", "FL", "33610", "8135559999"}, // counted, not changed
+			{"Austin", "OK", "73301", "5125551234"},    // 733xx is Texas, not Oklahoma
+			{"Denver", "CO", "", "3035550000"},         // no ZIP invented by default
+			{"", "", "32099", "9045550000"},            // not in the seed, so the state is a range guess
+		},
+	}
+	got, stats, err := PopulateGeo(ds, GeoOptions{})
+	if err != nil {
+		t.Fatalf("PopulateGeo returned error: %v", err)
+	}
+	if got.Rows[0][0] != "Tampa" || got.Rows[1][0] != "Ybor City" {
+		t.Errorf("unexpected cities %q, %q", got.Rows[0][0], got.Rows[1][0])
+	}
+	if got.Rows[2][1] != "TX" {
+		t.Errorf("expected 73301 to be corrected to TX, got %q", got.Rows[2][1])
+	}
+	if got.Rows[3][2] != "" {
+		t.Errorf("expected no placeholder ZIP, got %q", got.Rows[3][2])
+	}
+	if got.Rows[4][1] != "FL" || got.Rows[4][0] != "" {
+		t.Errorf("expected 32099 to give FL and no city, got %v", got.Rows[4])
+	}
+	if stats.PopulatedCity != 1 || stats.CityMismatches != 1 || stats.CorrectedMismatches != 1 || stats.PopulatedZip != 0 || stats.StateFromZipRange != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	got, _, _ = PopulateGeo(ds, GeoOptions{PlaceholderZips: true})
+	if got.Rows[3][2] == "" {
+		t.Error("expected a placeholder ZIP when PlaceholderZips is set")
+	}
+}
+
 func TestValidateStates(t *testing.T) {
 	ds := &extract.DataSet{
 		Headers: []string{"SourceID", "First", "Middle", "Last", "Address", "City", "State", "Zip", "Phone", "Address3", "Province", "Email", "TrustedURL"},
@@ -153,7 +443,10 @@ func TestValidateStates(t *testing.T) {
 			{"7", "", "", "", "", "", "XX", "", "", "", "", "", ""},
 		},
 	}
-	result := ValidateStates(ds)
+	result, err := ValidateStates(ds)
+	if err != nil {
+		t.Fatalf("ValidateStates returned error: %v", err)
+	}
 
 	if len(result.Cleaned.Rows) != 4 {
 		t.Errorf("expected 4 valid rows, got %d", len(result.Cleaned.Rows))
@@ -162,3 +455,199 @@ func TestValidateStates(t *testing.T) {
 		t.Errorf("expected 3 dropped invalid rows, got %d", result.DropCount)
 	}
 }
+
+// sliceWriter collects rows written by Stream.
+type sliceWriter struct{ rows [][]string }
+
+func (w *sliceWriter) Write(row []string) error {
+	w.rows = append(w.rows, row)
+	return nil
+}
+
+func TestStreamMatchesDataSetTransforms(t *testing.T) {
+	ds := mockData()
+	schema := ds.Schema()
+
+	address, _ := AddressCleaner(schema)
+	phones, _ := PhoneNormalizer(schema)
+	dedup, _ := PhoneDeduper(schema)
+
+	var out sliceWriter
+	stats, err := Stream(ds.Stream(), &out, nil, address, phones, dedup)
+	if err != nil {
+		t.Fatalf("Stream returned error: %v", err)
+	}
+	if stats.Read != len(ds.Rows) || stats.Written != len(out.rows) {
+		t.Errorf("unexpected stats %+v for %d written rows", stats, len(out.rows))
+	}
+
+	want, _ := CleanAddresses(ds)
+	want, _, _ = NormalizePhones(want)
+	res, _ := DedupPhones(want)
+	if len(out.rows) != len(res.Cleaned.Rows) {
+		t.Fatalf("expected %d rows, got %d", len(res.Cleaned.Rows), len(out.rows))
+	}
+	for i := range out.rows {
+		for j := range out.rows[i] {
+			if out.rows[i][j] != res.Cleaned.Rows[i][j] {
+				t.Errorf("row %d col %d: stream %q, dataset %q", i, j, out.rows[i][j], res.Cleaned.Rows[i][j])
+			}
+		}
+	}
+}
+
+func TestTransformsDoNotModifyInput(t *testing.T) {
+	ds := mockData()
+	if _, _, err := CleanNames(ds); err != nil {
+		t.Fatalf("CleanNames returned error: %v", err)
+	}
+	if ds.Rows[0][1] != "J4son" {
+		t.Errorf("input row was modified: %q", ds.Rows[0][1])
+	}
+}
+
+// largeData returns mockData's rows repeated to at least n rows, each with
+// its own source id and line.
+func largeData(n int) *extract.DataSet {
+	base := mockData()
+	ds := &extract.DataSet{Headers: base.Headers}
+	for i := 0; len(ds.Rows) < n; i++ {
+		row := slices.Clone(base.Rows[i%len(base.Rows)])
+		row[0] = strconv.Itoa(i + 1)
+		ds.Rows = append(ds.Rows, row)
+	}
+	return ds
+}
+
+// withWorkers runs fn with Workers and chunkRows set, restoring them after.
+func withWorkers(workers, chunk int, fn func()) {
+	oldWorkers, oldChunk := Workers, chunkRows
+	Workers, chunkRows = workers, chunk
+	defer func() { Workers, chunkRows = oldWorkers, oldChunk }()
+	fn()
+}
+
+func TestParallelMatchesSequential(t *testing.T) {
+	ds := largeData(1000)
+
+	type result struct {
+		rows    [][]string
+		lines   []int
+		rejects []types.Reject
+		names   types.NameStats
+		geo     types.GeoStats
+	}
+	run := func() result {
+		var r result
+		out, _ := CleanAddresses(ds)
+		out, r.names, _ = CleanNames(out)
+		out, _ = CleanEmails(out)
+		out, _ = CleanStates(out)
+		out, r.rejects, _ = NormalizePhones(out)
+		out, r.geo, _ = PopulateGeo(out, GeoOptions{})
+		v, _ := ValidateStates(out)
+		r.rejects = append(r.rejects, v.Dropped...)
+		r.rows, r.lines = v.Cleaned.Rows, v.Cleaned.Lines
+		return r
+	}
+
+	var sequential, parallel result
+	withWorkers(1, chunkRows, func() { sequential = run() })
+	withWorkers(4, 7, func() { parallel = run() })
+
+	if !reflect.DeepEqual(sequential, parallel) {
+		t.Errorf("parallel run differs from sequential:\nnames %+v vs %+v\ngeo %+v vs %+v\n%d vs %d rows, %d vs %d rejects",
+			sequential.names, parallel.names, sequential.geo, parallel.geo,
+			len(sequential.rows), len(parallel.rows), len(sequential.rejects), len(parallel.rejects))
+	}
+	if sequential.names.CleanedNumeric == 0 || sequential.geo.CorrectedMismatches == 0 {
+		t.Errorf("expected the fixture to exercise the stats, got %+v %+v", sequential.names, sequential.geo)
+	}
+}
+
+func TestApplyParallelReportsBuildError(t *testing.T) {
+	ds := largeData(100)
+	ds.Headers = slices.Clone(ds.Headers)
+	ds.Headers[1] = "Nickname" // no first name column
+	withWorkers(4, 7, func() {
+		if _, _, err := CleanNames(ds); err == nil {
+			t.Error("expected an error for the missing first name column")
+		}
+	})
+}
+
+// benchmarkRowTransforms runs the row-local clean-all steps over rows rows.
+func benchmarkRowTransforms(b *testing.B, rows, workers int) {
+	ds := largeData(rows)
+	withWorkers(workers, chunkRows, func() {
+		for b.Loop() {
+			out, _ := CleanAddresses(ds)
+			out, _, _ = CleanNames(out)
+			out, _ = CleanEmails(out)
+			out, _ = CleanStates(out)
+			out, _, _ = NormalizePhones(out)
+			PopulateGeo(out, GeoOptions{})
+		}
+	})
+}
+
+func BenchmarkRowTransforms(b *testing.B) {
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			benchmarkRowTransforms(b, 200_000, workers)
+		})
+	}
+}
+
+func TestProfileDataSet(t *testing.T) {
+	p, err := ProfileDataSet(mockData())
+	if err != nil {
+		t.Fatalf("ProfileDataSet returned error: %v", err)
+	}
+	if p.Rows != 11 || len(p.Columns) != 13 {
+		t.Fatalf("expected 11 rows and 13 columns, got %d and %d", p.Rows, len(p.Columns))
+	}
+
+	city := p.Columns[5]
+	if city.Filled != 11 || city.Distinct != 8 || city.MinLength != 5 || city.MaxLength != 7 {
+		t.Errorf("unexpected city profile: %+v", city)
+	}
+	if city.TopValues[0] != (ValueCount{"Chicago", 2}) {
+		t.Errorf("expected Chicago to be the top city, got %+v", city.TopValues)
+	}
+	if p.Columns[8].Type != TypePhone || p.Columns[0].Type != TypeNumeric || p.Columns[9].Type != TypeEmpty {
+		t.Errorf("unexpected types: phone %s, source id %s, address3 %s", p.Columns[8].Type, p.Columns[0].Type, p.Columns[9].Type)
+	}
+
+	// Bob's 555 area code and Eve's 999 fail normalize-phones; 33A10 and
+	// ABCDE are blanked by populate-geo
+	rejects := map[string]int{}
+	for _, c := range p.Columns {
+		for _, r := range c.Rejects {
+			rejects[r.Step] = r.Rejected
+		}
+	}
+	want := map[string]int{"normalize-phones": 2, "populate-geo": 2, "clean-states": 2, "validate-states": 3, "clean-email": 4}
+	if !reflect.DeepEqual(rejects, want) {
+		t.Errorf("rejects = %v, want %v", rejects, want)
+	}
+}
+
+func TestValueType(t *testing.T) {
+	tests := map[string]string{
+		"jo@example.com": TypeEmail,
+		"33610":          TypeZip,
+		"33610-1234":     TypeZip,
+		"(813) 555-9999": TypePhone,
+		"fl":             TypeState,
+		"2024-03-01":     TypeDate,
+		"3/1/2024":       TypeDate,
+		"1,250.50":       TypeNumeric,
+		"123 Main St":    TypeText,
+	}
+	for v, want := range tests {
+		if got := valueType(v); got != want {
+			t.Errorf("valueType(%q) = %s, want %s", v, got, want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"etl_go/extract"
+	"etl_go/types"
 )
 
 // AllowedStates is the list of valid US state codes + DC.
@@ -24,49 +25,49 @@ var AllowedStates = map[string]bool{
 // ValidationResult holds the cleaned dataset and any rows that were dropped.
 type ValidationResult struct {
 	Cleaned   *extract.DataSet
-	Dropped   [][]string
+	Dropped   []types.Reject
 	DropCount int
 }
 
 // ValidateStates removes rows with invalid state codes (non-50 states + DC).
 // It returns a ValidationResult for later reporting.
-func ValidateStates(ds *extract.DataSet) *ValidationResult {
+func ValidateStates(ds *extract.DataSet) (*ValidationResult, error) {
 	if ds == nil {
 		fmt.Println("No dataset loaded.")
-		return &ValidationResult{Cleaned: ds}
+		return &ValidationResult{Cleaned: ds}, nil
 	}
 
-	var (
-		validRows [][]string
-		dropped   [][]string
-	)
-
-	for _, row := range ds.Rows {
-		if len(row) <= 6 {
-			// Malformed row — drop it
-			dropped = append(dropped, row)
-			continue
-		}
-
-		state := strings.ToUpper(strings.TrimSpace(row[6]))
-		if AllowedStates[state] {
-			validRows = append(validRows, row)
-		} else {
-			dropped = append(dropped, row)
-		}
-	}
-
-	dropCount := len(dropped)
-
-	cleaned := &extract.DataSet{
-		Headers: ds.Headers,
-		Rows:    validRows,
-		Source:  ds.Source,
+	fn, err := StateValidator(ds.Schema())
+	if err != nil {
+		return nil, err
 	}
+	cleaned, dropped := ApplyParallel(ds, fn)
 
 	return &ValidationResult{
 		Cleaned:   cleaned,
 		Dropped:   dropped,
-		DropCount: dropCount,
+		DropCount: len(dropped),
+	}, nil
+}
+
+// StateValidator returns the per-row form of ValidateStates.
+func StateValidator(schema *extract.Schema) (RowFunc, error) {
+	cols, err := schema.Require(extract.RoleState)
+	if err != nil {
+		return nil, fmt.Errorf("validate-states: %w", err)
 	}
+	stateIdx := cols[0]
+
+	return func(line int, row []string) ([]string, string) {
+		if stateIdx >= len(row) {
+			// Malformed row — drop it
+			return row, types.ReasonMalformedRow
+		}
+
+		state := strings.ToUpper(strings.TrimSpace(row[stateIdx]))
+		if !AllowedStates[state] {
+			return row, types.ReasonInvalidState
+		}
+		return row, ""
+	}, nil
 }
@@ -1,11 +1,73 @@
 package types
 
+import "strings"
+
 // GeoStats holds geographic data cleaning statistics
 type GeoStats struct {
-	CleanedZipLetters    int
-	CleanedZipTooShort   int
-	PopulatedZip         int
-	PopulatedState       int
-	CorrectedMismatches  int
-	FixedFromAreaCode    int
-}
\ No newline at end of file
+	CleanedZipLetters   int `json:"cleaned_zip_letters"`
+	CleanedZipTooShort  int `json:"cleaned_zip_too_short"`
+	PopulatedZip        int `json:"populated_zip"`
+	PopulatedState      int `json:"populated_state"`
+	CorrectedMismatches int `json:"corrected_mismatches"`
+	FixedFromAreaCode   int `json:"fixed_from_area_code"`
+	PopulatedTimezone   int `json:"populated_timezone"`
+	PopulatedCity       int `json:"populated_city"`
+	CityMismatches      int `json:"city_mismatches"`      // city differs from the ZIP's primary city
+	StateFromZipRange   int `json:"state_from_zip_range"` // state guessed from the ZIP range table, not the ZIP table
+}
+
+// Add accumulates o into g.
+func (g *GeoStats) Add(o GeoStats) {
+	g.CleanedZipLetters += o.CleanedZipLetters
+	g.CleanedZipTooShort += o.CleanedZipTooShort
+	g.PopulatedZip += o.PopulatedZip
+	g.PopulatedState += o.PopulatedState
+	g.CorrectedMismatches += o.CorrectedMismatches
+	g.FixedFromAreaCode += o.FixedFromAreaCode
+	g.PopulatedTimezone += o.PopulatedTimezone
+	g.PopulatedCity += o.PopulatedCity
+	g.CityMismatches += o.CityMismatches
+	g.StateFromZipRange += o.StateFromZipRange
+}
+
+// NameStats counts name fields changed by clean-names.
+type NameStats struct {
+	CleanedNumeric int `json:"cleaned_numeric"` // cleared because they contained digits
+	CleanedSpecial int `json:"cleaned_special"` // had special characters stripped
+}
+
+// Add accumulates o into n.
+func (n *NameStats) Add(o NameStats) {
+	n.CleanedNumeric += o.CleanedNumeric
+	n.CleanedSpecial += o.CleanedSpecial
+}
+
+// Reason codes recorded for rejected rows.
+const (
+	ReasonInvalidState = "invalid_state"
+	ReasonMissingPhone = "missing_phone"
+	ReasonMissingName  = "missing_name"
+	ReasonDuplicateOf  = "duplicate_of" // written as duplicate_of:<line of the kept row>
+	ReasonMalformedRow = "malformed_row"
+	ReasonInvalidPhone = "invalid_phone" // written as invalid_phone:<problem>
+	ReasonExistingLead = "existing_lead" // written as existing_lead:<where it was found>
+	ReasonDNC          = "dnc"           // written as dnc:<which list>
+
+	ReasonFuzzyDuplicateOf = "fuzzy_duplicate_of" // written as fuzzy_duplicate_of:<line of the kept row>
+)
+
+// Reject is a row removed by a pipeline step, with the reason code and the
+// line it came from in the source file (the header is line 1).
+type Reject struct {
+	Line    int      `json:"line"`
+	Reason  string   `json:"reason"`
+	Row     []string `json:"row"`
+	Headers []string `json:"-"` // header row in effect when the row was dropped
+}
+
+// Code returns the reason without any detail suffix, so every
+// duplicate_of:<line> reports as duplicate_of.
+func (r Reject) Code() string {
+	code, _, _ := strings.Cut(r.Reason, ":")
+	return code
+}
@@ -23,6 +23,9 @@ func (m model) View() string {
 	if m.width == 0 {
 		return "Loading..."
 	}
+	if m.grid != nil {
+		return m.grid.View()
+	}
 
 	m.steps[0].status = true
 
@@ -67,6 +70,10 @@ func (m model) renderChecklist() string {
 		}
 		steps = append(steps, style.Render(fmt.Sprintf("%s %s", status, step.name)))
 	}
+	if m.running != nil {
+		steps = append(steps, "")
+		steps = append(steps, m.running.renderProgress(28)...)
+	}
 
 	return lipgloss.NewStyle().
 		Width(30).
@@ -122,23 +129,45 @@ func (m model) renderLegend() string {
 	legend := []string{
 		"Legend:",
 		"  show ............ preview first 5 rows",
+		"  browse [line] ... page through every row (/ search, : jump)",
 		"  drop <indexes> .. remove columns",
+		"  columns ......... show which column each role maps to",
+		"  profile [col] [json] summarize fill, values and type per column",
+		"  sheets .......... list worksheets in an .xlsx file",
+		"  use-sheet <s> ... load another worksheet",
+		"  merge-sheets [s]  stack sheets sharing a header (default all)",
+		"  map <role> <col>  assign a role to a column",
 		"  clean-address ... sanitize address fields",
 		"  clean-names ..... remove numbers & special chars from names",
 		"  clean-email ..... make sure there are no numeric values",
 		"  clean-states .... make sure there are no numeric values or invalid strings",
-		"  normalize-phones. format phone numbers",
+		"  normalize-phones. format phone numbers, drop invalid ones",
 		"  dedup-phones .... remove duplicate phone numbers",
-		"  populate-geo .... fill missing geo fields",
+		"  dedup-existing .. drop phones already loaded or on DNC",
+		"  dedup-fuzzy [merge] find the same person under different phones",
+		"  clusters ........ review the rows dedup-fuzzy matched",
+		"  populate-geo .... fill missing geo fields (placeholder-zips to invent ZIPs)",
 		"  validate-states . drop non-US states",
 		"  final-validate .. drop rows missing name/phone",
-		"  clean-all ....... run entire automated pipeline",
+		"  clean-all ....... run entire automated pipeline (prints the report)",
 		"  write-csv ....... export cleaned CSV",
-		"  write-report .... summary report",
+		"  write-xlsx [report] export cleaned XLSX (report adds a summary sheet)",
+		"  write-report .... summary report, saved next to the source",
+		"  rejects ......... count removed rows by reason",
+		"  write-rejects ... export removed rows (csv | xlsx)",
+		"  quarantine ...... show CSV lines that could not be parsed",
+		"  load-vicidial <list> [dry-run] insert rows into vicidial_list",
+		"  load-recipe <f> . replay a saved recipe",
+		"  save-recipe [f] . save this session as a recipe",
+		"  update-geo-data [area-codes|zips] [f] reload geo data",
+		"  undo / redo ..... step back or forward through snapshots",
+		"  history ......... list snapshots",
+		"  checkout <n> .... restore snapshot n",
 		"  exit ............ quit",
 		"",
 		"Navigation:",
 		"  TAB ............ switch focus",
+		"  Esc ............ cancel the running step",
 		"  ← → ............ scroll horizontally (when output focused)",
 		"  ↑ ↓ ............ scroll vertically (when output focused)",
 	}
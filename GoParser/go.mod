@@ -1,3 +1,7 @@
 module go-parser
 
 go 1.25.1
+
+require leadkit v0.0.0
+
+replace leadkit => ../leadkit
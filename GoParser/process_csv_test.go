@@ -1,6 +1,9 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -69,6 +72,16 @@ func TestPopulateZip_UsesStateMapping(t *testing.T) {
 	}
 }
 
+func TestPopulateZip_MinnesotaPlaceholderIsInMinnesota(t *testing.T) {
+	// The old table filled MN rows with 54403, a Wisconsin ZIP
+	row := make([]string, 13)
+	row[6] = "MN"
+	populateZip(row)
+	if row[7] != "55101" {
+		t.Errorf("Expected MN ZIP 55101, got %s", row[7])
+	}
+}
+
 func TestPopulateZip_DoesNothingIfZipAlreadyPresent(t *testing.T) {
 	row := make([]string, 13)
 	row[6] = "CA"
@@ -90,6 +103,28 @@ func TestPopulateStateFromZip_FindsMatchingState(t *testing.T) {
 	}
 }
 
+func TestPopulateStateFromZip_OverlappingRangesResolved(t *testing.T) {
+	// These ZIPs sat in two states' ranges in the old table, which then
+	// picked either at random
+	for zip, want := range map[string]string{
+		"73301": "TX", // not OK
+		"06390": "NY", // not CT
+		"83414": "WY", // not ID
+		"39813": "GA", // not MS
+		"05501": "MA", // not VT
+		"20201": "DC", // not VA
+		"20601": "MD", // not VA
+		"00802": "VI", // not PR
+	} {
+		row := make([]string, 13)
+		row[7] = zip
+		populateStateFromZip(row)
+		if row[6] != want {
+			t.Errorf("ZIP %s: expected %s, got %s", zip, want, row[6])
+		}
+	}
+}
+
 func TestPopulateStateFromZip_UnknownZipDoesNothing(t *testing.T) {
 	row := make([]string, 13)
 	row[7] = "999999"
@@ -110,6 +145,16 @@ func TestPopulateStateZipFromAreaCode_FillsCorrectly(t *testing.T) {
 	}
 }
 
+func TestPopulateStateZipFromAreaCode_CoversEveryState(t *testing.T) {
+	// The old lookup only knew the area codes of 15 states
+	row := make([]string, 13)
+	row[8] = "2065550101" // Seattle
+	populateStateZipFromAreaCode(row)
+	if row[6] != "WA" || row[7] != "98001" {
+		t.Errorf("Expected WA/98001, got %s/%s", row[6], row[7])
+	}
+}
+
 func TestPopulateStateZipFromAreaCode_UnknownAreaCode(t *testing.T) {
 	row := make([]string, 13)
 	row[8] = "0009998888"
@@ -118,3 +163,73 @@ func TestPopulateStateZipFromAreaCode_UnknownAreaCode(t *testing.T) {
 		t.Errorf("Expected no inference, got %s/%s", row[6], row[7])
 	}
 }
+
+// --- 7. ZIP sanitizing ---
+
+func TestSanitizeZip_KeepsLeadingZero(t *testing.T) {
+	// New England ZIPs start with 0; the old copy of this check blanked them.
+	got := sanitizeZip("02108-1234")
+	if got != "02108" {
+		t.Errorf("Expected 02108, got %s", got)
+	}
+}
+
+func TestSanitizeZip_RejectsLettersAndShortZips(t *testing.T) {
+	for _, zip := range []string{"9021", "A1B 2C3", ""} {
+		if got := sanitizeZip(zip); got != "" {
+			t.Errorf("sanitizeZip(%q) = %s, want blank", zip, got)
+		}
+	}
+}
+
+// --- 8. Whole-file parity ---
+
+// testdata/leads_expected.csv is what the processor wrote before it moved
+// onto leadkit: the old binary run on testdata/leads.csv. The output must
+// match it byte for byte except for the rows below, keyed by source_id.
+// Each is an intended change with its own test above.
+var intendedChanges = map[string]string{
+	// Area codes cover every state (TestPopulateStateZipFromAreaCode_CoversEveryState)
+	"10": "10,Jo,,Sun,10 Oak,,WA,98001,2065550101,,,,",
+	// Leading zeros are kept, so the ZIP gives the state (TestSanitizeZip_KeepsLeadingZero)
+	"13": "13,Mo,,Wu,13 Elm,Boston,MA,02108,6175550000,,,,",
+}
+
+func TestProcessCSV_MatchesExpectedOutput(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.csv")
+	if err := processCSV("testdata/leads.csv", out); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("testdata/leads_expected.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gotLines := strings.SplitAfter(string(got), "\n")
+	wantLines := strings.SplitAfter(string(want), "\n")
+	if len(gotLines) != len(wantLines) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(gotLines), len(wantLines), got)
+	}
+	changed := 0
+	for i, line := range gotLines {
+		id, _, _ := strings.Cut(wantLines[i], ",")
+		if change, ok := intendedChanges[id]; ok {
+			changed++
+			if wantLines[i] == change+"\n" {
+				t.Errorf("row %s: listed as changed but the old output was the same", id)
+			}
+			if line != change+"\n" {
+				t.Errorf("row %s:\ngot  %q\nwant %q", id, line, change)
+			}
+		} else if line != wantLines[i] {
+			t.Errorf("row %s differs from the old output:\ngot  %q\nwant %q", id, line, wantLines[i])
+		}
+	}
+	if changed != len(intendedChanges) {
+		t.Errorf("only %d of %d intended changes are in the fixture", changed, len(intendedChanges))
+	}
+}
@@ -0,0 +1,185 @@
+// Package astguiclient reads VICIdial's astguiclient.conf and connects to
+// the dialer database it names, so the Go tools don't need the Perl stub to
+// find it.
+package astguiclient
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// DefaultPath is where VICIdial keeps its server settings.
+const DefaultPath = "/etc/astguiclient.conf"
+
+// PathEnv names a conf file to use when no --conf path is given.
+const PathEnv = "ASTGUICLIENT_CONF"
+
+// Config holds the database settings from astguiclient.conf.
+type Config struct {
+	Server   string // VARDB_server
+	Database string // VARDB_database
+	User     string // VARDB_user
+	Pass     string // VARDB_pass
+	Port     int    // VARDB_port
+}
+
+// Keys are the conf keys Config reads. Each can be overridden by an
+// environment variable of the same name in upper case, e.g. VARDB_SERVER.
+var Keys = []string{"VARDB_server", "VARDB_database", "VARDB_user", "VARDB_pass", "VARDB_port"}
+
+// noise matches what the VICIdial Perl scripts strip from each line before
+// splitting it: spaces, tabs, the > of "=>", and # or ; comments.
+var noise = regexp.MustCompile(`[ \t>]|[#;].*$`)
+
+// defaults returns the settings used for keys the file leaves out.
+func defaults() Config {
+	return Config{Server: "localhost", Database: "asterisk", Port: 3306}
+}
+
+// Parse reads conf lines from r, accepting both "VARDB_server => host" and
+// "VARDB_server=host". Blank values are ignored and missing keys fall back
+// to localhost:3306/asterisk.
+func Parse(r io.Reader) (Config, error) {
+	c := defaults()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(noise.ReplaceAllString(scanner.Text(), ""))
+		key, val, ok := strings.Cut(line, "=")
+		if !ok || val == "" {
+			continue
+		}
+		if err := c.set(key, val); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Config{}, err
+	}
+	return c, nil
+}
+
+// Read parses the conf file at path.
+func Read(path string) (Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	c, err := Parse(f)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Resolve picks the conf file to read: path if set, else $ASTGUICLIENT_CONF,
+// else DefaultPath.
+func Resolve(path string) string {
+	if path != "" {
+		return path
+	}
+	if env := os.Getenv(PathEnv); env != "" {
+		return env
+	}
+	return DefaultPath
+}
+
+// Load reads the conf file chosen by Resolve(path) and applies environment
+// overrides on top of it.
+func Load(path string) (Config, error) {
+	c, err := Read(Resolve(path))
+	if err != nil {
+		return Config{}, err
+	}
+	if err := c.ApplyEnv(os.Getenv); err != nil {
+		return Config{}, err
+	}
+	return c, nil
+}
+
+// ApplyEnv overrides each of Keys with getenv(upper-cased key) when that is
+// non-empty.
+func (c *Config) ApplyEnv(getenv func(string) string) error {
+	for _, key := range Keys {
+		name := strings.ToUpper(key)
+		if val := getenv(name); val != "" {
+			if err := c.set(key, val); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// set stores one conf value. Keys Config doesn't hold are ignored.
+func (c *Config) set(key, val string) error {
+	switch key {
+	case "VARDB_server":
+		c.Server = val
+	case "VARDB_database":
+		c.Database = val
+	case "VARDB_user":
+		c.User = val
+	case "VARDB_pass":
+		c.Pass = val
+	case "VARDB_port":
+		port, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("bad VARDB_port %q", val)
+		}
+		c.Port = port
+	}
+	return nil
+}
+
+// DSN returns the go-sql-driver/mysql data source name for the config.
+func (c Config) DSN() string {
+	mc := mysql.NewConfig()
+	mc.User = c.User
+	mc.Passwd = c.Pass
+	mc.Net = "tcp"
+	mc.Addr = net.JoinHostPort(c.Server, strconv.Itoa(c.Port))
+	mc.DBName = c.Database
+	mc.Params = map[string]string{"charset": "utf8mb4"}
+	return mc.FormatDSN()
+}
+
+// Open connects to the configured database and checks it answers.
+func (c Config) Open(ctx context.Context) (*sql.DB, error) {
+	db, err := sql.Open("mysql", c.DSN())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to %s on %s:%d: %w", c.Database, c.Server, c.Port, err)
+	}
+	return db, nil
+}
+
+// Open loads the config as Load does and connects to its database.
+func Open(ctx context.Context, path string) (*sql.DB, error) {
+	c, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return c.Open(ctx)
+}
+
+// Flag registers the --conf flag on fs. Its value is meant for Load or
+// Open; left empty, they fall back to $ASTGUICLIENT_CONF and DefaultPath.
+func Flag(fs *flag.FlagSet) *string {
+	return fs.String("conf", "", "astguiclient.conf with the dialer DB settings (default $"+PathEnv+" or "+DefaultPath+")")
+}
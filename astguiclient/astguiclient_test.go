@@ -0,0 +1,60 @@
+package astguiclient
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	conf := "# database settings\n" +
+		"VARDB_server => 10.0.0.5\n" +
+		"VARDB_user => cron ; the default user\n" +
+		"VARDB_pass=1234\n" +
+		"VARDB_port => \n" +
+		"VARserver_ip => 10.0.0.9\n"
+
+	got, err := Parse(strings.NewReader(conf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Config{Server: "10.0.0.5", Database: "asterisk", User: "cron", Pass: "1234", Port: 3306}
+	if got != want {
+		t.Errorf("Parse = %+v, want %+v", got, want)
+	}
+	if dsn := got.DSN(); dsn != "cron:1234@tcp(10.0.0.5:3306)/asterisk?charset=utf8mb4" {
+		t.Errorf("DSN = %q", dsn)
+	}
+
+	if _, err := Parse(strings.NewReader("VARDB_port => 33o6\n")); err == nil {
+		t.Error("expected an error for a bad port")
+	}
+}
+
+func TestLoadOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "astguiclient.conf")
+	if err := os.WriteFile(path, []byte("VARDB_server => 10.0.0.5\nVARDB_user => cron\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(PathEnv, path)
+	t.Setenv("VARDB_SERVER", "db.example.com")
+	t.Setenv("VARDB_PORT", "3307")
+
+	got, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Config{Server: "db.example.com", Database: "asterisk", User: "cron", Port: 3307}
+	if got != want {
+		t.Errorf("Load = %+v, want %+v", got, want)
+	}
+
+	if Resolve("/opt/vici.conf") != "/opt/vici.conf" {
+		t.Error("an explicit path should win over $" + PathEnv)
+	}
+	t.Setenv(PathEnv, "")
+	if Resolve("") != DefaultPath {
+		t.Errorf("Resolve(\"\") = %q, want %q", Resolve(""), DefaultPath)
+	}
+}
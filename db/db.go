@@ -1,70 +1,230 @@
 package main
 
 import (
+	"embed"
+	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
 	"os"
+	"os/user"
+	"path/filepath"
 	"runtime"
+	"strings"
+	"text/template"
+	"time"
 )
 
-const perlTemplate = `#!/usr/bin/perl
-use strict;
-use warnings;
-use DBI;
-
-# -------------------------------------------------------------------------
-# Load DB config
-# -------------------------------------------------------------------------
-my $PATHconf = '/etc/astguiclient.conf';
-open(my $conf_fh, '<', $PATHconf) or die "Can't open $PATHconf: $!\n";
-my %conf;
-while (my $line = <$conf_fh>) {
-    $line =~ s/[#;].*$//;
-    $line =~ s/^\s+|\s+$//g;
-    next unless $line =~ /=/;
-    my ($key, $val) = split /=/, $line, 2;
-    $conf{$key} = $val if defined $val && $val ne '';
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Features picks the optional blocks a script is generated with.
+type Features struct {
+	DBI     bool // connect to the asterisk DB
+	CSVOut  bool // write results to <name>.csv
+	Logging bool // timestamped log to stderr and <name>.log
+}
+
+// featureNames maps --with values to the Features field they set.
+var featureNames = map[string]func(*Features){
+	"dbi":     func(f *Features) { f.DBI = true },
+	"csv-out": func(f *Features) { f.CSVOut = true },
+	"logging": func(f *Features) { f.Logging = true },
+}
+
+// ScriptData is what the templates are executed with.
+type ScriptData struct {
+	Name        string // script name without extension
+	Author      string
+	Description string
+	Table       string // table the sample query reads
+	Date        string
+	With        Features
+}
+
+// target describes one --lang: the files it writes, keyed by the template
+// that produces them, and whether the result is executable.
+type target struct {
+	files      func(path string) map[string]string // template -> output path
+	executable bool
+	hint       string // printed after the files are written
+}
+
+var targets = map[string]target{
+	"perl": {
+		files:      func(path string) map[string]string { return map[string]string{"perl.tmpl": withExt(path, ".pl")} },
+		executable: true,
+	},
+	"bash": {
+		files:      func(path string) map[string]string { return map[string]string{"bash.tmpl": withExt(path, ".sh")} },
+		executable: true,
+	},
+	"go": {
+		// A directory holding its own module, since the driver is a dependency
+		files: func(path string) map[string]string {
+			dir := strings.TrimSuffix(path, filepath.Ext(path))
+			return map[string]string{
+				"go.tmpl":     filepath.Join(dir, "main.go"),
+				"go.mod.tmpl": filepath.Join(dir, "go.mod"),
+			}
+		},
+		hint: "Run 'go mod tidy' in %s before building.",
+	},
+}
+
+// stub is the target for "db <filename>": the Perl script written to
+// exactly the path given, as db did before "db new" existed.
+var stub = target{
+	files:      func(path string) map[string]string { return map[string]string{"perl.tmpl": path} },
+	executable: true,
+}
+
+// withExt adds ext to path unless it already has an extension.
+func withExt(path, ext string) string {
+	if filepath.Ext(path) != "" {
+		return path
+	}
+	return path + ext
 }
-close $conf_fh;
-
-my $VARDB_server   = $conf{'VARDB_server'}   // 'localhost';
-my $VARDB_database = $conf{'VARDB_database'} // 'asterisk';
-my $VARDB_user     = $conf{'VARDB_user'}     // '';
-my $VARDB_pass     = $conf{'VARDB_pass'}     // '';
-my $VARDB_port     = $conf{'VARDB_port'}     // 3306;
-
-# -------------------------------------------------------------------------
-# DB Connection
-# -------------------------------------------------------------------------
-my $dbh = DBI->connect(
-    "DBI:mysql:$VARDB_database:$VARDB_server:$VARDB_port",
-    $VARDB_user,
-    $VARDB_pass,
-    { RaiseError => 1, AutoCommit => 1, mysql_enable_utf8 => 1 }
-) or die "Couldn't connect to database: " . DBI->errstr;
-`
 
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Println("Usage: db <filename>")
+		usage()
 		os.Exit(1)
 	}
 
-	filename := os.Args[1]
-
-	// Write the file (Windows ignores perms, Unix honors them)
-	err := os.WriteFile(filename, []byte(perlTemplate), 0644)
+	var err error
+	if os.Args[1] == "new" {
+		err = runNew(os.Args[2:])
+	} else {
+		// db <filename>: the original Perl stub with a DB connection
+		err = write(stub, os.Args[1], ScriptData{Table: "vicidial_list", With: Features{DBI: true}}, false)
+	}
 	if err != nil {
-		fmt.Printf("Error writing file: %v\n", err)
+		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
+}
+
+func usage() {
+	fmt.Println("Usage: db new [--lang perl|go|bash] [--with dbi,csv-out,logging] [--author name]")
+	fmt.Println("              [--description text] [--table vicidial_list] [--force] <name>")
+	fmt.Println("       db <filename>   (Perl script with a DB connection)")
+}
+
+// runNew handles "db new".
+func runNew(args []string) error {
+	fset := flag.NewFlagSet("new", flag.ContinueOnError)
+	lang := fset.String("lang", "perl", "script language: perl, go or bash")
+	with := fset.String("with", "dbi", "comma-separated features: dbi, csv-out, logging")
+	author := fset.String("author", defaultAuthor(), "author for the header comment")
+	description := fset.String("description", "", "one-line description for the header comment")
+	table := fset.String("table", "vicidial_list", "table the sample query reads")
+	force := fset.Bool("force", false, "overwrite existing files")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if fset.NArg() != 1 {
+		usage()
+		return fmt.Errorf("expected one script name")
+	}
+
+	features, err := parseFeatures(*with)
+	if err != nil {
+		return err
+	}
+	data := ScriptData{Author: *author, Description: *description, Table: *table, With: features}
+	return generate(strings.ToLower(*lang), fset.Arg(0), data, *force)
+}
+
+// parseFeatures reads a --with list.
+func parseFeatures(list string) (Features, error) {
+	var f Features
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		set, ok := featureNames[name]
+		if !ok {
+			return f, fmt.Errorf("unknown feature %q (expected dbi, csv-out or logging)", name)
+		}
+		set(&f)
+	}
+	return f, nil
+}
+
+// generate renders every file of the lang target for the script at path.
+// Existing files are left alone unless force is set.
+func generate(lang, path string, data ScriptData, force bool) error {
+	t, ok := targets[lang]
+	if !ok {
+		return fmt.Errorf("unknown language %q (expected perl, go or bash)", lang)
+	}
+	return write(t, path, data, force)
+}
+
+// write renders the files of target t for the script at path.
+func write(t target, path string, data ScriptData, force bool) error {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	data.Name = filepath.Base(base)
+	data.Date = time.Now().Format("2006-01-02")
+
+	files := t.files(path)
+	if !force {
+		for _, out := range files {
+			if _, err := os.Stat(out); err == nil {
+				return fmt.Errorf("%s already exists (use --force to overwrite)", out)
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+	}
+
+	for tmplName, out := range files {
+		if err := render(tmplName, out, data); err != nil {
+			return err
+		}
+		if t.executable && runtime.GOOS != "windows" {
+			if err := os.Chmod(out, 0755); err != nil {
+				fmt.Printf("Warning: couldn't set executable bit: %v\n", err)
+			}
+		}
+		fmt.Printf("Created %s\n", out)
+	}
+	if t.hint != "" {
+		fmt.Printf(t.hint+"\n", base)
+	}
+	return nil
+}
 
-	// If Unix-like, apply chmod +x
-	if runtime.GOOS != "windows" {
-		err = os.Chmod(filename, 0755)
-		if err != nil {
-			fmt.Printf("Warning: couldn't set executable bit: %v\n", err)
+// render executes one embedded template into out, creating its directory.
+func render(tmplName, out string, data ScriptData) error {
+	tmpl, err := template.ParseFS(templateFS, "templates/"+tmplName)
+	if err != nil {
+		return fmt.Errorf("loading template %s: %w", tmplName, err)
+	}
+	if dir := filepath.Dir(out); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
 		}
 	}
 
-	fmt.Printf("Created %s\n", filename)
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(f, data); err != nil {
+		f.Close()
+		return fmt.Errorf("rendering %s: %w", out, err)
+	}
+	return f.Close()
+}
+
+// defaultAuthor is the login name of the user running db, if known.
+func defaultAuthor() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
 }
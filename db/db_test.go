@@ -0,0 +1,93 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateEveryTarget(t *testing.T) {
+	all := Features{DBI: true, CSVOut: true, Logging: true}
+	for _, with := range []Features{{}, {CSVOut: true}, all} {
+		dir := t.TempDir()
+		data := ScriptData{Author: "jk", Description: "nightly export", Table: "vicidial_list", With: with}
+		for _, lang := range []string{"perl", "bash", "go"} {
+			if err := generate(lang, filepath.Join(dir, "export"), data, false); err != nil {
+				t.Fatalf("%s %+v: %v", lang, with, err)
+			}
+		}
+
+		src, err := os.ReadFile(filepath.Join(dir, "export", "main.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := parser.ParseFile(token.NewFileSet(), "main.go", src, 0); err != nil {
+			t.Errorf("generated Go with %+v does not parse: %v", with, err)
+		}
+		for _, name := range []string{"export.pl", "export.sh"} {
+			script, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(script), "VARDB_server") {
+				t.Errorf("%s doesn't read astguiclient.conf", name)
+			}
+			if got := strings.Contains(string(script), "vicidial_list"); got != (with.DBI && with.CSVOut) {
+				t.Errorf("%s with %+v: sample query present = %v", name, with, got)
+			}
+		}
+	}
+}
+
+func TestGenerateRefusesToOverwrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stub.pl")
+	if err := os.WriteFile(path, []byte("keep me"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := generate("perl", path, ScriptData{}, false); err == nil {
+		t.Fatal("expected an error for an existing file")
+	}
+	if b, _ := os.ReadFile(path); string(b) != "keep me" {
+		t.Error("existing file was modified without --force")
+	}
+	if err := generate("perl", path, ScriptData{}, true); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path); string(b) == "keep me" {
+		t.Error("--force did not overwrite the file")
+	}
+}
+
+func TestStubKeepsExactPath(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"cleanup", "report.cgi"} {
+		path := filepath.Join(dir, name)
+		if err := write(stub, path, ScriptData{Table: "vicidial_list", With: Features{DBI: true}}, false); err != nil {
+			t.Fatal(err)
+		}
+		script, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("db %s didn't write %s: %v", name, name, err)
+		}
+		if !strings.HasPrefix(string(script), "#!/usr/bin/perl") {
+			t.Errorf("%s isn't a Perl script", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "cleanup.pl")); err == nil {
+		t.Error("db cleanup also wrote cleanup.pl")
+	}
+}
+
+func TestParseFeatures(t *testing.T) {
+	got, err := parseFeatures("dbi, CSV-out")
+	if err != nil || got != (Features{DBI: true, CSVOut: true}) {
+		t.Errorf("parseFeatures = %+v, %v", got, err)
+	}
+	if _, err := parseFeatures("dbi,ftp"); err == nil {
+		t.Error("expected an error for an unknown feature")
+	}
+}
@@ -0,0 +1,70 @@
+// mkzips builds the full ZIP5 table from the GeoNames US postal code file
+// (https://download.geonames.org/export/zip/US.zip, unzipped to US.txt):
+//
+//	go run ./cmd/mkzips -version geonames-2026.10 US.txt zips.csv.gz
+//
+// The output is what 'update-geo-data zips' and 'etl_go run --zip-data'
+// load, and can replace geodata/zips.csv.gz.
+package main
+
+import (
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"leadkit/geodata"
+)
+
+func main() {
+	version := flag.String("version", "", "version recorded in the table (required)")
+	flag.Usage = func() {
+		fmt.Println("Usage: mkzips -version <v> <US.txt> <out.csv[.gz]>")
+	}
+	flag.Parse()
+	if flag.NArg() != 2 || *version == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+	if err := run(*version, flag.Arg(0), flag.Arg(1)); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(version, in, out string) error {
+	src, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	t, err := geodata.ParseGeoNamesZips(src, version)
+	if err != nil {
+		return fmt.Errorf("%s: %w", in, err)
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(out, ".gz") {
+		zw := gzip.NewWriter(f)
+		err = geodata.WriteZips(zw, t)
+		if cerr := zw.Close(); err == nil {
+			err = cerr
+		}
+	} else {
+		err = geodata.WriteZips(f, t)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %d ZIP codes to %s (version %s)\n", t.Len(), out, version)
+	return nil
+}
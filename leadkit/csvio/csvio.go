@@ -0,0 +1,89 @@
+// Package csvio reads and writes CSV files: whole files for tools that work
+// in memory, and a record at a time for ones that stream their output.
+package csvio
+
+import (
+	"encoding/csv"
+	"os"
+)
+
+// ReadOptions controls ReadAll.
+type ReadOptions struct {
+	// LazyQuotes accepts a quote in an unquoted field and a non-doubled
+	// quote in a quoted field, as encoding/csv's LazyQuotes does.
+	LazyQuotes bool
+}
+
+// ReadAll reads every record of the CSV file at path, header included. Every
+// record must have as many fields as the first. A file that can't be opened
+// is reported with the *fs.PathError from os.Open, so callers can tell it
+// apart from a parse error.
+func ReadAll(path string, opts ReadOptions) ([][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.LazyQuotes = opts.LazyQuotes
+	return r.ReadAll()
+}
+
+// WriteAll writes header, unless it is nil, and then rows to a new CSV file
+// at path. As with reading, a file that can't be created is reported with
+// the *fs.PathError from os.Create.
+func WriteAll(path string, header []string, rows [][]string) error {
+	w, err := Create(path, header)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			w.f.Close()
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// Writer writes a CSV file one record at a time, for tools that stream rows
+// rather than holding them all.
+type Writer struct {
+	f *os.File
+	w *csv.Writer
+}
+
+// Create creates the CSV file at path and writes header to it, unless it is
+// nil. A file that can't be created is reported with the *fs.PathError from
+// os.Create.
+func Create(path string, header []string) (*Writer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &Writer{f: f, w: csv.NewWriter(f)}
+	if header != nil {
+		if err := w.Write(header); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// Write appends one record. Records are buffered, so a failed write may
+// only be reported by a later Write or by Close.
+func (w *Writer) Write(record []string) error {
+	return w.w.Write(record)
+}
+
+// Close flushes buffered records and closes the file.
+func (w *Writer) Close() error {
+	w.w.Flush()
+	if err := w.w.Error(); err != nil {
+		w.f.Close()
+		return err
+	}
+	return w.f.Close()
+}
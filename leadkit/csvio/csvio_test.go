@@ -0,0 +1,79 @@
+package csvio
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv")
+	rows := [][]string{{"1", `say "hi"`}, {"2", "a,b"}}
+	if err := WriteAll(path, []string{"id", "note"}, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadAll(path, ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append([][]string{{"id", "note"}}, rows...)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadAll = %q, want %q", got, want)
+	}
+}
+
+func TestReadAllLazyQuotes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "in.csv")
+	if err := os.WriteFile(path, []byte("id,email\n1,jo\"@example.com\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadAll(path, ReadOptions{}); err == nil {
+		t.Error("expected a parse error without LazyQuotes")
+	}
+	got, err := ReadAll(path, ReadOptions{LazyQuotes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[1][1] != `jo"@example.com` {
+		t.Errorf("lazy field = %q", got[1][1])
+	}
+
+	var pathErr *fs.PathError
+	if _, err := ReadAll(filepath.Join(t.TempDir(), "missing.csv"), ReadOptions{}); !errors.As(err, &pathErr) {
+		t.Errorf("missing file error = %v, want a *fs.PathError", err)
+	}
+}
+
+func TestWriterStreams(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv")
+	w, err := Create(path, []string{"id", "note"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range [][]string{{"1", "first"}, {"2", "line\nbreak"}} {
+		if err := w.Write(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadAll(path, ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"id", "note"}, {"1", "first"}, {"2", "line\nbreak"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadAll = %q, want %q", got, want)
+	}
+	var pathErr *fs.PathError
+	if _, err := Create(filepath.Join(t.TempDir(), "missing", "out.csv"), nil); !errors.As(err, &pathErr) {
+		t.Errorf("Create error = %v, want a *fs.PathError", err)
+	}
+}
@@ -0,0 +1,36 @@
+// Package dedup tracks which phone numbers a file has already used.
+package dedup
+
+import "leadkit/phone"
+
+// PhoneIndex remembers every normalized phone number it has seen and the
+// line it was first seen on.
+type PhoneIndex struct {
+	first map[string]int
+}
+
+// NewPhoneIndex returns an empty index.
+func NewPhoneIndex() *PhoneIndex {
+	return &PhoneIndex{first: make(map[string]int)}
+}
+
+// See normalizes raw (see phone.Normalize) and records it as seen on line.
+// It returns the normalized number and, when the number was seen before,
+// the line it was first seen on with dup set. Blank numbers are never
+// duplicates.
+func (x *PhoneIndex) See(raw string, line int) (num string, first int, dup bool) {
+	num = phone.Normalize(raw)
+	if num == "" {
+		return "", 0, false
+	}
+	if first, ok := x.first[num]; ok {
+		return num, first, true
+	}
+	x.first[num] = line
+	return num, 0, false
+}
+
+// Len returns the number of distinct phone numbers seen.
+func (x *PhoneIndex) Len() int {
+	return len(x.first)
+}
@@ -0,0 +1,30 @@
+package dedup
+
+import "testing"
+
+func TestPhoneIndex(t *testing.T) {
+	x := NewPhoneIndex()
+	steps := []struct {
+		raw   string
+		line  int
+		num   string
+		first int
+		dup   bool
+	}{
+		{"(305) 555-1234", 2, "3055551234", 0, false},
+		{"", 3, "", 0, false},
+		{"1-305-555-1234", 4, "3055551234", 2, true},
+		{"", 5, "", 0, false},
+		{"404 555 0000", 6, "4045550000", 0, false},
+		{"+1 305.555.1234", 7, "3055551234", 2, true},
+	}
+	for _, s := range steps {
+		num, first, dup := x.See(s.raw, s.line)
+		if num != s.num || first != s.first || dup != s.dup {
+			t.Errorf("See(%q, %d) = %q, %d, %v; want %q, %d, %v", s.raw, s.line, num, first, dup, s.num, s.first, s.dup)
+		}
+	}
+	if x.Len() != 2 {
+		t.Errorf("Len = %d, want 2", x.Len())
+	}
+}
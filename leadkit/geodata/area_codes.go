@@ -0,0 +1,129 @@
+package geodata
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // timezone names are checked even where the OS has no zoneinfo
+)
+
+// Kind classifies an area code by the type of line behind it.
+type Kind string
+
+const (
+	KindGeographic    Kind = "geographic"
+	KindTollFree      Kind = "toll_free"
+	KindPremium       Kind = "premium"
+	KindPersonal      Kind = "personal"
+	KindNonGeographic Kind = "non_geographic"
+)
+
+// AreaCode describes one NANP numbering plan area.
+type AreaCode struct {
+	NPA      string
+	Region   string // state, province or territory; the country code in the Caribbean
+	Country  string
+	Kind     Kind
+	Timezone string // IANA name, empty for non-geographic codes
+}
+
+// AreaCodeTable is one version of the area code dataset.
+type AreaCodeTable struct {
+	Version string
+	codes   map[string]AreaCode
+}
+
+// Len returns the number of area codes in the table.
+func (t *AreaCodeTable) Len() int {
+	return len(t.codes)
+}
+
+// Lookup returns the entry for a three-digit area code.
+func (t *AreaCodeTable) Lookup(npa string) (AreaCode, bool) {
+	ac, ok := t.codes[npa]
+	return ac, ok
+}
+
+//go:embed area_codes.csv
+var areaCodesCSV string
+
+var (
+	areaCodesMu sync.RWMutex
+	areaCodes   *AreaCodeTable
+)
+
+// AreaCodes returns the table in use: the embedded one unless
+// SetAreaCodes replaced it.
+func AreaCodes() *AreaCodeTable {
+	areaCodesMu.RLock()
+	t := areaCodes
+	areaCodesMu.RUnlock()
+	if t != nil {
+		return t
+	}
+
+	areaCodesMu.Lock()
+	defer areaCodesMu.Unlock()
+	if areaCodes == nil {
+		t, err := ParseAreaCodes(strings.NewReader(areaCodesCSV))
+		if err != nil {
+			// The table is compiled in, so this only happens if it was edited badly
+			panic(fmt.Sprintf("geodata: embedded area code table: %v", err))
+		}
+		areaCodes = t
+	}
+	return areaCodes
+}
+
+// SetAreaCodes replaces the table used by LookupAreaCode.
+func SetAreaCodes(t *AreaCodeTable) {
+	areaCodesMu.Lock()
+	areaCodes = t
+	areaCodesMu.Unlock()
+}
+
+// LookupAreaCode returns the current table's entry for a three-digit area code.
+func LookupAreaCode(npa string) (AreaCode, bool) {
+	return AreaCodes().Lookup(npa)
+}
+
+// LoadAreaCodes reads an area code table from a file in the embedded format.
+func LoadAreaCodes(path string) (*AreaCodeTable, error) {
+	return loadFile(path, ParseAreaCodes)
+}
+
+// ParseAreaCodes reads the npa,region,country,kind,timezone table.
+func ParseAreaCodes(r io.Reader) (*AreaCodeTable, error) {
+	version, records, err := readTable(r, "npa,region,country,kind,timezone")
+	if err != nil {
+		return nil, err
+	}
+
+	t := &AreaCodeTable{Version: version, codes: make(map[string]AreaCode, len(records))}
+	for _, rec := range records {
+		ac := AreaCode{NPA: rec[0], Region: rec[1], Country: rec[2], Kind: Kind(rec[3]), Timezone: rec[4]}
+		if len(ac.NPA) != 3 {
+			return nil, fmt.Errorf("bad area code %q", ac.NPA)
+		}
+		if _, dup := t.codes[ac.NPA]; dup {
+			return nil, fmt.Errorf("area code %s listed twice", ac.NPA)
+		}
+		if err := checkTimezone(ac.Timezone); err != nil {
+			return nil, fmt.Errorf("area code %s: %w", ac.NPA, err)
+		}
+		t.codes[ac.NPA] = ac
+	}
+	return t, nil
+}
+
+// checkTimezone reports an error for a non-empty name that isn't an IANA zone.
+func checkTimezone(name string) error {
+	if name == "" {
+		return nil
+	}
+	_, err := time.LoadLocation(name)
+	return err
+}
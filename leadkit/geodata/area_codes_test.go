@@ -0,0 +1,73 @@
+package geodata
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEmbeddedAreaCodes(t *testing.T) {
+	tests := []struct {
+		npa, region, tz string
+		kind            Kind
+	}{
+		{"206", "WA", "America/Los_Angeles", KindGeographic},
+		{"850", "FL", "America/Chicago", KindGeographic},
+		{"915", "TX", "America/Denver", KindGeographic},
+		{"416", "ON", "America/Toronto", KindGeographic},
+		{"888", "", "", KindTollFree},
+		{"728", "FL", "America/New_York", KindGeographic}, // 561 overlay, 2025
+		{"879", "NL", "America/St_Johns", KindGeographic},
+	}
+	for _, tt := range tests {
+		ac, ok := LookupAreaCode(tt.npa)
+		if !ok {
+			t.Errorf("%s: not found", tt.npa)
+			continue
+		}
+		if ac.Region != tt.region || ac.Timezone != tt.tz || ac.Kind != tt.kind {
+			t.Errorf("%s: got %+v", tt.npa, ac)
+		}
+	}
+	if AreaCodes().Version == "" {
+		t.Error("embedded table has no version")
+	}
+}
+
+func TestParseAreaCodesRejectsBadTables(t *testing.T) {
+	const header = "npa,region,country,kind,timezone\n"
+	tests := map[string]string{
+		"no version":   header + "206,WA,US,geographic,America/Los_Angeles\n",
+		"bad timezone": "# version: x\n" + header + "206,WA,US,geographic,America/Seatle\n",
+		"duplicate":    "# version: x\n" + header + "206,WA,US,geographic,\n206,WA,US,geographic,\n",
+		"old format":   "# version: x\nnpa,region,country,kind\n206,WA,US,geographic\n",
+	}
+	for name, data := range tests {
+		if _, err := ParseAreaCodes(strings.NewReader(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	tbl, err := ParseAreaCodes(strings.NewReader("# version: test-1\n" + header + "206,WA,US,geographic,America/Los_Angeles\n"))
+	if err != nil {
+		t.Fatalf("valid table: %v", err)
+	}
+	if tbl.Version != "test-1" || tbl.Len() != 1 {
+		t.Errorf("got version %q with %d codes", tbl.Version, tbl.Len())
+	}
+}
+
+func TestEmbeddedZips(t *testing.T) {
+	z, ok := LookupZip("32501")
+	if !ok {
+		t.Fatal("32501 not in the seed table")
+	}
+	if z.City != "Pensacola" || z.State != "FL" || z.Timezone != "America/Chicago" || z.CountyFIPS != "12033" {
+		t.Errorf("unexpected record %+v", z)
+	}
+	if !Zips().Seed() {
+		t.Errorf("expected the embedded table to be marked as a seed, version %q", Zips().Version)
+	}
+	if _, err := ParseZips(strings.NewReader("# version: x\nzip,city,state,county_fips,timezone,lat,lon\n3250,Pensacola,FL,12033,,,\n")); err == nil {
+		t.Error("expected an error for a four-digit ZIP")
+	}
+}
@@ -0,0 +1,172 @@
+package geodata
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// stateFIPS is the two-digit FIPS code of each state and territory, used to
+// turn GeoNames' three-digit county codes into five-digit county FIPS.
+var stateFIPS = map[string]string{
+	"AL": "01", "AK": "02", "AZ": "04", "AR": "05", "CA": "06", "CO": "08", "CT": "09", "DE": "10",
+	"DC": "11", "FL": "12", "GA": "13", "HI": "15", "ID": "16", "IL": "17", "IN": "18", "IA": "19",
+	"KS": "20", "KY": "21", "LA": "22", "ME": "23", "MD": "24", "MA": "25", "MI": "26", "MN": "27",
+	"MS": "28", "MO": "29", "MT": "30", "NE": "31", "NV": "32", "NH": "33", "NJ": "34", "NM": "35",
+	"NY": "36", "NC": "37", "ND": "38", "OH": "39", "OK": "40", "OR": "41", "PA": "42", "RI": "44",
+	"SC": "45", "SD": "46", "TN": "47", "TX": "48", "UT": "49", "VT": "50", "VA": "51", "WA": "53",
+	"WV": "54", "WI": "55", "WY": "56", "AS": "60", "GU": "66", "MP": "69", "PR": "72", "VI": "78",
+}
+
+// stateTimezone is the timezone covering most of each state. Counties on
+// the other side of a timezone line are listed in countyTimezone.
+var stateTimezone = map[string]string{
+	"AL": "America/Chicago", "AK": "America/Anchorage", "AZ": "America/Phoenix", "AR": "America/Chicago",
+	"CA": "America/Los_Angeles", "CO": "America/Denver", "CT": "America/New_York", "DE": "America/New_York",
+	"DC": "America/New_York", "FL": "America/New_York", "GA": "America/New_York", "HI": "Pacific/Honolulu",
+	"ID": "America/Boise", "IL": "America/Chicago", "IN": "America/Indiana/Indianapolis", "IA": "America/Chicago",
+	"KS": "America/Chicago", "KY": "America/New_York", "LA": "America/Chicago", "ME": "America/New_York",
+	"MD": "America/New_York", "MA": "America/New_York", "MI": "America/Detroit", "MN": "America/Chicago",
+	"MS": "America/Chicago", "MO": "America/Chicago", "MT": "America/Denver", "NE": "America/Chicago",
+	"NV": "America/Los_Angeles", "NH": "America/New_York", "NJ": "America/New_York", "NM": "America/Denver",
+	"NY": "America/New_York", "NC": "America/New_York", "ND": "America/Chicago", "OH": "America/New_York",
+	"OK": "America/Chicago", "OR": "America/Los_Angeles", "PA": "America/New_York", "RI": "America/New_York",
+	"SC": "America/New_York", "SD": "America/Chicago", "TN": "America/Chicago", "TX": "America/Chicago",
+	"UT": "America/Denver", "VT": "America/New_York", "VA": "America/New_York", "WA": "America/Los_Angeles",
+	"WV": "America/New_York", "WI": "America/Chicago", "WY": "America/Denver", "AS": "Pacific/Pago_Pago",
+	"GU": "Pacific/Guam", "MP": "Pacific/Saipan", "PR": "America/Puerto_Rico", "VI": "America/St_Thomas",
+}
+
+// countyTimezone overrides stateTimezone for counties in split states.
+// Counties whose line runs through the middle keep the state's timezone.
+var countyTimezone = map[string]string{}
+
+func init() {
+	for tz, counties := range map[string][]string{
+		"America/Chicago": {
+			// Florida panhandle west of the Apalachicola
+			"12005", "12013", "12033", "12059", "12063", "12091", "12113", "12131", "12133",
+			// Northwest and southwest Indiana
+			"18051", "18073", "18089", "18091", "18111", "18123", "18127", "18129", "18147", "18149", "18163", "18173",
+			// Western Kentucky
+			"21001", "21003", "21007", "21009", "21027", "21031", "21033", "21035", "21039", "21047",
+			"21053", "21055", "21057", "21059", "21061", "21075", "21083", "21085", "21087", "21091",
+			"21099", "21101", "21105", "21107", "21139", "21141", "21143", "21145", "21149", "21157",
+			"21169", "21171", "21177", "21183", "21207", "21213", "21219", "21221", "21225", "21227", "21233",
+		},
+		"America/Kentucky/Louisville": {"21111"},
+		"America/New_York": {
+			// East Tennessee
+			"47001", "47009", "47011", "47013", "47019", "47025", "47029", "47057", "47059", "47063",
+			"47065", "47067", "47073", "47089", "47091", "47093", "47105", "47107", "47121", "47123",
+			"47129", "47139", "47143", "47145", "47151", "47155", "47163", "47171", "47173", "47179",
+		},
+		"America/Menominee": {"26043", "26053", "26071", "26109"},
+		"America/Denver": {
+			// Kansas
+			"20071", "20075", "20181", "20199",
+			// Nebraska panhandle
+			"31005", "31007", "31013", "31029", "31033", "31045", "31049", "31057", "31069", "31075",
+			"31091", "31101", "31105", "31123", "31135", "31157", "31161", "31165",
+			// Southwest North Dakota
+			"38001", "38007", "38011", "38033", "38037", "38041", "38085", "38087", "38089",
+			// West River South Dakota
+			"46007", "46019", "46031", "46033", "46041", "46047", "46055", "46063", "46071", "46081",
+			"46093", "46102", "46103", "46105", "46113", "46117", "46137",
+			// Far west Texas
+			"48141", "48229",
+		},
+		"America/Boise":       {"41045"},
+		"America/Los_Angeles": {"16009", "16017", "16021", "16035", "16049", "16055", "16057", "16061", "16069", "16079"},
+		"America/Adak":        {"02016"},
+	} {
+		for _, c := range counties {
+			countyTimezone[c] = tz
+		}
+	}
+}
+
+// zipTimezone returns the timezone for a ZIP in state and county, or ""
+// for state codes with no fixed place (the military AA, AE and AP).
+func zipTimezone(state, county string) string {
+	if tz, ok := countyTimezone[county]; ok {
+		return tz
+	}
+	return stateTimezone[state]
+}
+
+// ParseGeoNamesZips builds a ZIP table from the GeoNames postal code dump
+// for the US (US.txt from download.geonames.org/export/zip). GeoNames has
+// no timezone column, so timezones come from the state and county. Rows
+// for military ZIPs are skipped, and so are repeats of a ZIP, which keeps
+// the first place listed.
+func ParseGeoNamesZips(r io.Reader, version string) (*ZipTable, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = '\t'
+	reader.FieldsPerRecord = 12
+	reader.LazyQuotes = true
+
+	t := &ZipTable{Version: version, zips: make(map[string]Zip)}
+	for {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		code, city, state := rec[1], rec[2], rec[4]
+		if rec[0] != "US" || len(code) != 5 {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("line %d: not a US ZIP: %q", line, strings.Join(rec[:2], " "))
+		}
+		tz := zipTimezone(state, stateFIPS[state]+rec[6])
+		if tz == "" {
+			continue
+		}
+		if _, dup := t.zips[code]; dup {
+			continue
+		}
+
+		z := Zip{Code: code, City: city, State: state, Timezone: tz}
+		if fips, ok := stateFIPS[state]; ok && len(rec[6]) == 3 {
+			z.CountyFIPS = fips + rec[6]
+		}
+		if z.Lat, err = strconv.ParseFloat(rec[9], 64); err != nil {
+			return nil, fmt.Errorf("ZIP %s: bad latitude %q", code, rec[9])
+		}
+		if z.Lon, err = strconv.ParseFloat(rec[10], 64); err != nil {
+			return nil, fmt.Errorf("ZIP %s: bad longitude %q", code, rec[10])
+		}
+		t.zips[code] = z
+	}
+	return t, nil
+}
+
+// WriteZips writes t in the format ParseZips reads, sorted by ZIP.
+func WriteZips(w io.Writer, t *ZipTable) error {
+	if _, err := fmt.Fprintf(w, "# ZIP5 reference data: primary city, state, county FIPS, timezone and\n# approximate centroid.\n# version: %s\n", t.Version); err != nil {
+		return err
+	}
+
+	codes := make([]string, 0, len(t.zips))
+	for code := range t.zips {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	cw := csv.NewWriter(w)
+	cw.Write(strings.Split(zipHeader, ","))
+	for _, code := range codes {
+		z := t.zips[code]
+		cw.Write([]string{
+			z.Code, z.City, z.State, z.CountyFIPS, z.Timezone,
+			strconv.FormatFloat(z.Lat, 'f', 4, 64), strconv.FormatFloat(z.Lon, 'f', 4, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
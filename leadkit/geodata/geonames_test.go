@@ -0,0 +1,74 @@
+package geodata
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// US.txt rows: country, ZIP, place, state name, state, county name, county
+// code, community name, community code, lat, lon, accuracy.
+const geoNamesSample = "US\t32501\tPensacola\tFlorida\tFL\tEscambia\t033\t\t\t30.4221\t-87.2169\t4\n" +
+	"US\t32301\tTallahassee\tFlorida\tFL\tLeon\t073\t\t\t30.4286\t-84.2588\t4\n" +
+	"US\t37902\tKnoxville\tTennessee\tTN\tKnox\t093\t\t\t35.9629\t-83.9212\t4\n" +
+	"US\t37201\tNashville\tTennessee\tTN\tDavidson\t037\t\t\t36.1659\t-86.7844\t4\n" +
+	"US\t01001\tAgawam\tMassachusetts\tMA\tHampden\t013\t\t\t42.0702\t-72.6227\t4\n" +
+	"US\t01001\tFeeding Hills\tMassachusetts\tMA\tHampden\t013\t\t\t42.0702\t-72.6227\t4\n" +
+	"US\t09002\tAPO\t\tAE\t\t\t\t\t\t\t\n"
+
+func TestParseGeoNamesZips(t *testing.T) {
+	tbl, err := ParseGeoNamesZips(strings.NewReader(geoNamesSample), "geonames-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tbl.Len() != 5 {
+		t.Errorf("got %d ZIPs, want 5 (military ZIP and the repeat skipped)", tbl.Len())
+	}
+
+	tests := []struct {
+		zip, city, county, tz string
+	}{
+		{"32501", "Pensacola", "12033", "America/Chicago"},
+		{"32301", "Tallahassee", "12073", "America/New_York"},
+		{"37902", "Knoxville", "47093", "America/New_York"},
+		{"37201", "Nashville", "47037", "America/Chicago"},
+		{"01001", "Agawam", "25013", "America/New_York"},
+	}
+	for _, tt := range tests {
+		z, ok := tbl.Lookup(tt.zip)
+		if !ok || z.City != tt.city || z.CountyFIPS != tt.county || z.Timezone != tt.tz {
+			t.Errorf("%s: got %+v", tt.zip, z)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteZips(&buf, tbl); err != nil {
+		t.Fatal(err)
+	}
+	back, err := ParseZips(&buf)
+	if err != nil {
+		t.Fatalf("written table doesn't parse: %v", err)
+	}
+	if back.Version != "geonames-test" || back.Len() != tbl.Len() || back.Seed() {
+		t.Errorf("round trip gave version %q with %d ZIPs", back.Version, back.Len())
+	}
+	if z, _ := back.Lookup("32501"); z.Lat != 30.4221 || z.Lon != -87.2169 {
+		t.Errorf("32501 coordinates: got %v,%v", z.Lat, z.Lon)
+	}
+}
+
+func TestCountyTimezonesAreValid(t *testing.T) {
+	for county, tz := range countyTimezone {
+		if err := checkTimezone(tz); err != nil {
+			t.Errorf("county %s: %v", county, err)
+		}
+	}
+	for state, tz := range stateTimezone {
+		if err := checkTimezone(tz); err != nil {
+			t.Errorf("state %s: %v", state, err)
+		}
+		if _, ok := stateFIPS[state]; !ok {
+			t.Errorf("state %s has no FIPS code", state)
+		}
+	}
+}
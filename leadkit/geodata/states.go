@@ -0,0 +1,147 @@
+package geodata
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// --- STATE → ZIP ---
+// One placeholder ZIP per state, for tools that fill a missing ZIP from the
+// state rather than leaving it blank.
+var stateZip = map[string]string{
+	"AL": "35007", "AK": "99501", "AZ": "85304", "AR": "71602", "CA": "90005",
+	"CO": "80001", "CT": "06001", "DE": "19701", "DC": "20012", "FL": "32003",
+	"GA": "30002", "HI": "96701", "ID": "83203", "IL": "61081", "IN": "46011",
+	"IA": "50005", "KS": "66008", "KY": "40007", "LA": "70001", "ME": "04750",
+	"MD": "20601", "MA": "05544", "MI": "48706", "MN": "55101", "MS": "38601",
+	"MO": "64722", "MT": "59001", "NE": "68001", "NV": "88905", "NH": "03031",
+	"NJ": "07753", "NC": "28376", "NM": "87001", "NY": "10028", "ND": "58001",
+	"OH": "45434", "OK": "73002", "OR": "97009", "PA": "15001", "RI": "02823",
+	"SC": "29001", "SD": "57002", "TN": "37011", "TX": "73344",
+	"UT": "84002", "VT": "05009", "VA": "20101", "WA": "98001", "WV": "24712",
+	"WI": "54990", "WY": "82002", "PR": "00999", "VI": "00851",
+}
+
+// --- ZIP → STATE RANGE ---
+// Last resort for ZIPs missing from the ZIP table: a guess from the ZIP's
+// number alone, which can't tell a real ZIP from an unassigned one. Ranges
+// don't overlap.
+var zipCodeRanges = map[string][][2]int{
+	"AL": {{35000, 36999}}, "AK": {{99500, 99999}}, "AZ": {{85000, 86999}},
+	"AR": {{71600, 72999}}, "CA": {{90000, 96699}}, "CO": {{80000, 81999}},
+	"CT": {{6000, 6389}, {6391, 6999}}, "DE": {{19700, 19999}}, "FL": {{32000, 34999}},
+	"GA": {{30000, 31999}, {39800, 39999}}, "HI": {{96700, 96999}},
+	"ID": {{83200, 83413}, {83415, 83999}}, "IL": {{60000, 62999}}, "IN": {{46000, 47999}},
+	"IA": {{50000, 52999}}, "KS": {{66000, 67999}}, "KY": {{40000, 42999}},
+	"LA": {{70000, 71599}}, "ME": {{3900, 4999}}, "MD": {{20600, 21999}},
+	"MA": {{1000, 2799}, {5501, 5544}}, "MI": {{48000, 49999}},
+	"MN": {{55000, 56899}}, "MS": {{38600, 39799}}, "MO": {{63000, 65999}},
+	"MT": {{59000, 59999}}, "NC": {{27000, 28999}}, "ND": {{58000, 58999}},
+	"NE": {{68000, 69999}}, "NV": {{88900, 89999}}, "NH": {{3000, 3899}},
+	"NJ": {{7000, 8999}}, "NM": {{87000, 88499}},
+	"NY": {{10000, 14999}, {6390, 6390}, {501, 501}, {544, 544}},
+	"OH": {{43000, 45999}}, "OK": {{73000, 73199}, {73400, 74999}}, "OR": {{97000, 97999}},
+	"PA": {{15000, 19699}}, "RI": {{2800, 2999}}, "SC": {{29000, 29999}},
+	"SD": {{57000, 57999}}, "TN": {{37000, 38599}},
+	"TX": {{75000, 79999}, {73301, 73399}, {88500, 88599}},
+	"UT": {{84000, 84999}}, "VT": {{5000, 5499}, {5600, 5999}}, "VA": {{20100, 20199}, {22000, 24699}},
+	"DC": {{20000, 20099}, {20200, 20599}, {56900, 56999}},
+	"WA": {{98000, 99499}}, "WV": {{24700, 26999}}, "WI": {{53000, 54999}},
+	"WY": {{82000, 83199}, {83414, 83414}}, "PR": {{600, 799}, {900, 999}}, "VI": {{801, 851}},
+}
+
+// PlaceholderZip returns the placeholder ZIP for a two-letter state.
+func PlaceholderZip(state string) (string, bool) {
+	zip, ok := stateZip[state]
+	return zip, ok
+}
+
+// StateForZipRange returns the state whose ZIP ranges hold zip, or "".
+func StateForZipRange(zip string) string {
+	n, err := strconv.Atoi(zip)
+	if err != nil {
+		return ""
+	}
+	for _, state := range slices.Sorted(maps.Keys(zipCodeRanges)) {
+		for _, r := range zipCodeRanges[state] {
+			if n >= r[0] && n <= r[1] {
+				return state
+			}
+		}
+	}
+	return ""
+}
+
+// StateForZip returns the state a ZIP belongs to, from the ZIP table or,
+// failing that, the range table. fromRange reports the latter, so callers
+// can count how often they relied on the guess.
+func StateForZip(zip string) (state string, fromRange bool) {
+	if z, ok := LookupZip(zip); ok {
+		return z.State, false
+	}
+	state = StateForZipRange(zip)
+	return state, state != ""
+}
+
+// StateForAreaCode returns the US state or territory of a geographic area
+// code.
+func StateForAreaCode(npa string) (string, bool) {
+	ac, ok := LookupAreaCode(npa)
+	if !ok || ac.Kind != KindGeographic || ac.Country != "US" {
+		return "", false
+	}
+	return ac.Region, true
+}
+
+// NormalizeState upper-cases and trims a state code.
+func NormalizeState(state string) string {
+	return strings.ToUpper(strings.TrimSpace(state))
+}
+
+// CleanZip returns the five-digit ZIP in zip, dropping a +4 suffix and any
+// punctuation, or "" when zip holds letters or fewer than five digits.
+// Leading zeros are kept: New England and Puerto Rico ZIPs start with 0.
+func CleanZip(zip string) string {
+	zip = strings.TrimSpace(zip)
+	if HasLetters(zip) {
+		return ""
+	}
+
+	var digits strings.Builder
+	for _, r := range zip {
+		if unicode.IsDigit(r) {
+			digits.WriteRune(r)
+		}
+	}
+	cleaned := digits.String()
+	if len(cleaned) < 5 {
+		return ""
+	}
+	return cleaned[:5]
+}
+
+// HasLetters reports whether s contains any letters.
+func HasLetters(s string) bool {
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsZip reports whether zip is exactly five ASCII digits.
+func IsZip(zip string) bool {
+	if len(zip) != 5 {
+		return false
+	}
+	for _, r := range zip {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
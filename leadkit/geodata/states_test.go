@@ -0,0 +1,77 @@
+package geodata
+
+import "testing"
+
+func TestCleanZip(t *testing.T) {
+	tests := map[string]string{
+		"33101":       "33101",
+		" 02108-1234": "02108",
+		"331011234":   "33101",
+		"9021":        "",
+		"K1A 0B1":     "",
+		"":            "",
+	}
+	for in, want := range tests {
+		if got := CleanZip(in); got != want {
+			t.Errorf("CleanZip(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestStateLookups(t *testing.T) {
+	if got := StateForZipRange("06390"); got != "NY" {
+		t.Errorf("StateForZipRange(06390) = %q, want NY (Fishers Island)", got)
+	}
+	if got := StateForZipRange("00000"); got != "" {
+		t.Errorf("StateForZipRange(00000) = %q, want blank", got)
+	}
+	if state, ok := StateForAreaCode("512"); !ok || state != "TX" {
+		t.Errorf("StateForAreaCode(512) = %q, %v", state, ok)
+	}
+	for _, npa := range []string{"416", "800", "999"} { // Canadian, toll-free, unassigned
+		if state, ok := StateForAreaCode(npa); ok {
+			t.Errorf("StateForAreaCode(%s) = %q, want no US state", npa, state)
+		}
+	}
+	if zip, ok := PlaceholderZip("CT"); !ok || zip != "06001" {
+		t.Errorf("PlaceholderZip(CT) = %q, %v", zip, ok)
+	}
+	if zip, ok := PlaceholderZip("TX2"); ok {
+		t.Errorf("PlaceholderZip(TX2) = %q; TX2 isn't a state", zip)
+	}
+}
+
+func TestStateForZipFallsBackToRanges(t *testing.T) {
+	if state, fromRange := StateForZip("73301"); state != "TX" || fromRange {
+		t.Errorf("StateForZip(73301) = %q, %v; want TX from the ZIP table", state, fromRange)
+	}
+	// 32099 is a Jacksonville ZIP the seed table doesn't list
+	if state, fromRange := StateForZip("32099"); state != "FL" || !fromRange {
+		t.Errorf("StateForZip(32099) = %q, %v; want FL from the range table", state, fromRange)
+	}
+	if state, fromRange := StateForZip("00000"); state != "" || fromRange {
+		t.Errorf("StateForZip(00000) = %q, %v; want blank", state, fromRange)
+	}
+}
+
+func TestZipRangesDontOverlap(t *testing.T) {
+	owner := make(map[int]string)
+	for state, ranges := range zipCodeRanges {
+		for _, r := range ranges {
+			for n := r[0]; n <= r[1]; n++ {
+				if other, ok := owner[n]; ok {
+					t.Fatalf("ZIP %05d is in both %s and %s", n, other, state)
+				}
+				owner[n] = state
+			}
+		}
+	}
+}
+
+func TestPlaceholderZipsLieInTheirState(t *testing.T) {
+	for state, zip := range stateZip {
+		if got := StateForZipRange(zip); got != state {
+			t.Errorf("placeholder %s for %s is in %q", zip, state, got)
+		}
+	}
+}
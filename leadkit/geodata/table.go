@@ -0,0 +1,77 @@
+package geodata
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// readTable parses one of the CSV datasets in this package. Lines starting
+// with '#' are comments, except "# version: <v>" which names the dataset
+// version and is required. The header row must match header exactly.
+// Gzipped input is detected and decompressed.
+func readTable(r io.Reader, header string) (string, [][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", nil, err
+	}
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return "", nil, err
+		}
+		if data, err = io.ReadAll(zr); err != nil {
+			return "", nil, err
+		}
+	}
+
+	var version string
+	for _, line := range strings.Split(string(data), "\n") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(line), "# version:"); ok {
+			version = strings.TrimSpace(v)
+			break
+		}
+	}
+	if version == "" {
+		return "", nil, fmt.Errorf("missing '# version:' line")
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = strings.Count(header, ",") + 1
+
+	got, err := reader.Read()
+	if err != nil {
+		return "", nil, fmt.Errorf("reading header: %w", err)
+	}
+	if strings.Join(got, ",") != header {
+		return "", nil, fmt.Errorf("unexpected header %q, want %q", strings.Join(got, ","), header)
+	}
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return "", nil, err
+	}
+	return version, records, nil
+}
+
+// loadFile opens path and hands it to parse, naming the file in any error.
+func loadFile[T any](path string, parse func(io.Reader) (T, error)) (T, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	defer f.Close()
+
+	t, err := parse(f)
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
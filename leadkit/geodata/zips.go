@@ -0,0 +1,135 @@
+package geodata
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Zip is the reference record for one five-digit ZIP code.
+type Zip struct {
+	Code       string
+	City       string // USPS primary city
+	State      string
+	CountyFIPS string
+	Timezone   string
+	Lat, Lon   float64
+}
+
+// ZipTable is one version of the ZIP dataset.
+type ZipTable struct {
+	Version string
+	zips    map[string]Zip
+}
+
+// Len returns the number of ZIP codes in the table.
+func (t *ZipTable) Len() int {
+	return len(t.zips)
+}
+
+// Seed reports whether t is the embedded seed rather than a full ZIP file.
+func (t *ZipTable) Seed() bool {
+	return strings.HasPrefix(t.Version, "seed-")
+}
+
+// Lookup returns the record for a five-digit ZIP code.
+func (t *ZipTable) Lookup(zip string) (Zip, bool) {
+	z, ok := t.zips[zip]
+	return z, ok
+}
+
+// zipsGz is a seed of a few dozen ZIPs (state capitals and large cities),
+// enough to exercise the lookups. The full ZIP5 table isn't shipped with
+// the source: build it from the GeoNames US postal file with cmd/mkzips
+// and load it with SetZips. Until then most ZIPs fall back to the range
+// table.
+//
+//go:embed zips.csv.gz
+var zipsGz []byte
+
+var (
+	zipsMu sync.RWMutex
+	zips   *ZipTable
+)
+
+// Zips returns the table in use: the embedded seed unless SetZips replaced it.
+func Zips() *ZipTable {
+	zipsMu.RLock()
+	t := zips
+	zipsMu.RUnlock()
+	if t != nil {
+		return t
+	}
+
+	zipsMu.Lock()
+	defer zipsMu.Unlock()
+	if zips == nil {
+		t, err := ParseZips(bytes.NewReader(zipsGz))
+		if err != nil {
+			// The table is compiled in, so this only happens if it was edited badly
+			panic(fmt.Sprintf("geodata: embedded ZIP table: %v", err))
+		}
+		zips = t
+	}
+	return zips
+}
+
+// SetZips replaces the table used by LookupZip.
+func SetZips(t *ZipTable) {
+	zipsMu.Lock()
+	zips = t
+	zipsMu.Unlock()
+}
+
+// LookupZip returns the current table's record for a five-digit ZIP code.
+func LookupZip(zip string) (Zip, bool) {
+	return Zips().Lookup(zip)
+}
+
+// LoadZips reads a ZIP table from a file, plain or gzipped.
+func LoadZips(path string) (*ZipTable, error) {
+	return loadFile(path, ParseZips)
+}
+
+// zipHeader is the header row of a ZIP table.
+const zipHeader = "zip,city,state,county_fips,timezone,lat,lon"
+
+// ParseZips reads the zip,city,state,county_fips,timezone,lat,lon table.
+func ParseZips(r io.Reader) (*ZipTable, error) {
+	version, records, err := readTable(r, zipHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &ZipTable{Version: version, zips: make(map[string]Zip, len(records))}
+	checked := make(map[string]bool) // timezones already validated
+	for _, rec := range records {
+		z := Zip{Code: rec[0], City: rec[1], State: rec[2], CountyFIPS: rec[3], Timezone: rec[4]}
+		if len(z.Code) != 5 {
+			return nil, fmt.Errorf("bad ZIP code %q", z.Code)
+		}
+		if _, dup := t.zips[z.Code]; dup {
+			return nil, fmt.Errorf("ZIP %s listed twice", z.Code)
+		}
+		if !checked[z.Timezone] {
+			if err := checkTimezone(z.Timezone); err != nil {
+				return nil, fmt.Errorf("ZIP %s: %w", z.Code, err)
+			}
+			checked[z.Timezone] = true
+		}
+		if rec[5] != "" || rec[6] != "" {
+			if z.Lat, err = strconv.ParseFloat(rec[5], 64); err != nil {
+				return nil, fmt.Errorf("ZIP %s: bad latitude %q", z.Code, rec[5])
+			}
+			if z.Lon, err = strconv.ParseFloat(rec[6], 64); err != nil {
+				return nil, fmt.Errorf("ZIP %s: bad longitude %q", z.Code, rec[6])
+			}
+		}
+		t.zips[z.Code] = z
+	}
+	return t, nil
+}
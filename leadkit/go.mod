@@ -0,0 +1,3 @@
+module leadkit
+
+go 1.25.1
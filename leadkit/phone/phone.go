@@ -0,0 +1,88 @@
+// Package phone normalizes and validates North American phone numbers.
+package phone
+
+import (
+	"regexp"
+
+	"leadkit/geodata"
+)
+
+// Problems Validate reports.
+const (
+	BadLength     = "length"               // not 10 digits after dropping a leading 1
+	BadAreaCode   = "area_code"            // area code starts with 0 or 1
+	BadExchange   = "exchange"             // exchange starts with 0 or 1
+	N11           = "n11"                  // area code or exchange is 211-911
+	Fictional     = "fictional"            // 555-0100 through 555-0199
+	Unassigned    = "unassigned_area_code" // reserved by NANPA, never a real line
+	Unknown       = "unknown_area_code"    // not in the area code table; see Warning
+	TollFree      = "toll_free"
+	PremiumRate   = "premium_rate"
+	NonGeographic = "non_geographic" // personal (5XX) and carrier codes
+)
+
+// nonDigits matches everything Normalize strips.
+var nonDigits = regexp.MustCompile(`\D`)
+
+// Normalize keeps only the digits of a phone number and drops the leading 1
+// of an 11-digit number, so "+1 (813) 555-9999" becomes "8135559999". It
+// doesn't check the result; see Validate.
+func Normalize(raw string) string {
+	num := nonDigits.ReplaceAllString(raw, "")
+	if len(num) == 11 && num[0] == '1' {
+		num = num[1:]
+	}
+	return num
+}
+
+// Warning reports whether problem is one a number may still be real with.
+// New area codes go into service a few times a year, so a code missing from
+// the table is more likely a stale table than a bad number.
+func Warning(problem string) bool {
+	return problem == Unknown
+}
+
+// reserved reports whether NANPA keeps npa out of service: N9X codes for
+// expansion, 37X and 96X for unforeseen uses, 555 and 950.
+func reserved(npa string) bool {
+	return npa[1] == '9' || npa[:2] == "37" || npa[:2] == "96" || npa == "555" || npa == "950"
+}
+
+// Validate normalizes raw to ten digits and checks it against the NANP
+// numbering rules and the area code table in geodata. It returns the digits
+// and an empty problem when the number can be dialed as a regular line.
+func Validate(raw string) (string, string) {
+	num := Normalize(raw)
+	if len(num) != 10 {
+		return num, BadLength
+	}
+
+	npa, nxx, line := num[:3], num[3:6], num[6:]
+	switch {
+	case npa[0] == '0' || npa[0] == '1':
+		return num, BadAreaCode
+	case nxx[0] == '0' || nxx[0] == '1':
+		return num, BadExchange
+	case npa[1:] == "11" || nxx[1:] == "11":
+		return num, N11
+	case nxx == "555" && line >= "0100" && line <= "0199":
+		return num, Fictional
+	}
+
+	ac, ok := geodata.LookupAreaCode(npa)
+	switch {
+	case !ok && reserved(npa):
+		return num, Unassigned
+	case !ok:
+		return num, Unknown
+	}
+	switch ac.Kind {
+	case geodata.KindTollFree:
+		return num, TollFree
+	case geodata.KindPremium:
+		return num, PremiumRate
+	case geodata.KindPersonal, geodata.KindNonGeographic:
+		return num, NonGeographic
+	}
+	return num, ""
+}
@@ -0,0 +1,48 @@
+package phone
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"(813) 555-9999":   "8135559999",
+		"+1 702.555.0000":  "7025550000",
+		"1-214-555-9876":   "2145559876",
+		"":                 "",
+		"ext. 12":          "12",
+		"2135550000 x1234": "21355500001234",
+	}
+	for raw, want := range tests {
+		if got := Normalize(raw); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		raw, problem string
+	}{
+		{"(813) 555-9999", ""},
+		{"+1 702.555.0000", ""},
+		{"416-555-1234", ""}, // Toronto
+		{"813555999", BadLength},
+		{"2813555999", ""},
+		{"0135559999", BadAreaCode},
+		{"8130559999", BadExchange},
+		{"4115559999", N11},
+		{"8134115999", N11},
+		{"8135550142", Fictional},
+		{"5555555555", Unassigned},
+		{"3705551234", Unassigned},
+		{"2935551234", Unassigned}, // N9X expansion code
+		{"3665551234", Unknown},
+		{"8885551234", TollFree},
+		{"9005551234", PremiumRate},
+		{"5005551234", NonGeographic},
+	}
+	for _, tt := range tests {
+		if _, problem := Validate(tt.raw); problem != tt.problem {
+			t.Errorf("Validate(%q) problem = %q, want %q", tt.raw, problem, tt.problem)
+		}
+	}
+}